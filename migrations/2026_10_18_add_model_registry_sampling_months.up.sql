@@ -0,0 +1,4 @@
+-- Шаг снимков датасета, на котором обучена модель
+ALTER TABLE model_registry ADD COLUMN sampling_months INTEGER NOT NULL DEFAULT 12;
+
+COMMENT ON COLUMN model_registry.sampling_months IS 'Шаг снимков датасета обучения в месяцах; признаки для предсказания считаются с тем же шагом';
@@ -0,0 +1,23 @@
+-- Создание реестра ML-моделей
+CREATE TABLE model_registry (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,        -- Имя модели (model_{shop_type}_{period}_{bbox})
+    category VARCHAR(50) NOT NULL,            -- Тип объектов (shop_type)
+    train_start DATE NOT NULL,                -- Начало периода обучения
+    train_end DATE NOT NULL,                  -- Конец периода обучения
+    coverage GEOMETRY(Polygon, 4326) NOT NULL,-- Область, на которой обучена модель
+    feature_schema_version VARCHAR(20) NOT NULL, -- Версия набора признаков
+    metrics JSONB NOT NULL DEFAULT '{}',      -- Метрики валидации (mse, rmse, r2)
+    status VARCHAR(20) NOT NULL DEFAULT 'candidate', -- candidate, active, retired
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_model_registry_category ON model_registry (category);
+CREATE INDEX idx_model_registry_status ON model_registry (status);
+CREATE INDEX idx_model_registry_coverage ON model_registry USING GIST (coverage);
+
+COMMENT ON TABLE model_registry IS 'Реестр ML-моделей с метаданными и областью покрытия';
+COMMENT ON COLUMN model_registry.coverage IS 'Область обучения модели в SRID 4326';
+COMMENT ON COLUMN model_registry.feature_schema_version IS 'Версия набора признаков (model.FeatureSchemaVersion)';
+COMMENT ON COLUMN model_registry.metrics IS 'Метрики валидации модели в формате JSON';
+COMMENT ON COLUMN model_registry.status IS 'Статус модели: candidate, active или retired';
@@ -0,0 +1,35 @@
+-- Журнал предсказаний для теневой и canary-оценки моделей
+CREATE TABLE prediction_log (
+    id SERIAL PRIMARY KEY,
+    request_id VARCHAR(32) NOT NULL,          -- Общий идентификатор основного и теневого предсказания
+    role VARCHAR(20) NOT NULL,                -- primary, canary или shadow
+    model_name VARCHAR(255) NOT NULL,         -- Модель, выдавшая предсказание
+    bbox VARCHAR(100) NOT NULL,               -- Координаты области
+    shop_type VARCHAR(50) NOT NULL,           -- Тип объектов
+    prediction_year VARCHAR(10) NOT NULL,     -- Год предсказания
+    activity_level FLOAT NOT NULL,
+    predicted_new INTEGER NOT NULL,
+    predicted_closed INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_prediction_log_request ON prediction_log (request_id);
+CREATE INDEX idx_prediction_log_model ON prediction_log (model_name, role);
+CREATE INDEX idx_prediction_log_area ON prediction_log (bbox, shop_type, prediction_year);
+
+-- Фактические изменения, с которыми сверяются предсказания
+CREATE TABLE prediction_ground_truth (
+    id SERIAL PRIMARY KEY,
+    bbox VARCHAR(100) NOT NULL,
+    shop_type VARCHAR(50) NOT NULL,
+    year VARCHAR(10) NOT NULL,
+    actual_new INTEGER NOT NULL,
+    actual_closed INTEGER NOT NULL,
+    total_objects INTEGER NOT NULL,
+    recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (bbox, shop_type, year)
+);
+
+COMMENT ON TABLE prediction_log IS 'Предсказания основной модели и моделей-кандидатов';
+COMMENT ON COLUMN prediction_log.role IS 'Роль модели: primary (текущая), canary (кандидат, ответ клиенту), shadow (кандидат, только запись)';
+COMMENT ON TABLE prediction_ground_truth IS 'Фактические открытия и закрытия объектов за год';
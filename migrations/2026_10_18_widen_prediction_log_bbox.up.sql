@@ -0,0 +1,7 @@
+-- bbox с координатами полной точности (например, "55.781234567,37.581234567,...") не
+-- помещается в VARCHAR(100); формат совпадает с training_data.bbox
+ALTER TABLE prediction_log ALTER COLUMN bbox TYPE TEXT;
+ALTER TABLE prediction_ground_truth ALTER COLUMN bbox TYPE TEXT;
+
+COMMENT ON COLUMN prediction_log.bbox IS 'Bounding box в формате "minLat,minLon,maxLat,maxLon"';
+COMMENT ON COLUMN prediction_ground_truth.bbox IS 'Bounding box в формате "minLat,minLon,maxLat,maxLon"';
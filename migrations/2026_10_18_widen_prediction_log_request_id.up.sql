@@ -0,0 +1,4 @@
+-- Предсказания записываются под X-Request-ID запроса API, который может прислать клиент
+ALTER TABLE prediction_log ALTER COLUMN request_id TYPE VARCHAR(128);
+
+COMMENT ON COLUMN prediction_log.request_id IS 'Идентификатор запроса API (X-Request-ID), общий для основного и теневого предсказания';
@@ -6,6 +6,7 @@ import logging
 import glob
 from typing import Dict, List, Optional
 from flask import Flask, request, jsonify
+from sklearn.ensemble import RandomForestRegressor
 
 # Настройка логирования
 logging.basicConfig(
@@ -16,6 +17,24 @@ logger = logging.getLogger(__name__)
 
 app = Flask(__name__)
 
+# Признаки модели в порядке FEATURE_COLUMNS из train_models.py
+FEATURE_NAMES = [
+    'avg_area',
+    'avg_dist_to_primary',
+    'avg_dist_to_subway',
+    'closure_rate',
+    'new_object_rate',
+    'object_density',
+    'total_objects'
+]
+
+# Версия контракта с osm_service (mlclient.SchemaVersion), мажорная часть должна совпадать
+SCHEMA_VERSION = "1.3"
+
+class ModelNotFoundError(LookupError):
+    """Модели или скейлера нет среди обученных; ответ 404, а не ошибка сервиса"""
+
+
 class PredictionService:
     def __init__(self):
         # Переходим в корневую директорию проекта
@@ -34,7 +53,7 @@ class PredictionService:
             # Ищем все модели для данного типа магазина
             all_models = glob.glob(os.path.join(self.models_dir, f"model_{shop_type}_*.joblib"))
             if not all_models:
-                raise ValueError(f"No models found for shop type: {shop_type}")
+                raise ModelNotFoundError(f"No models found for shop type: {shop_type}")
             
             # Проверяем каждую модель
             for model_path in all_models:
@@ -60,11 +79,13 @@ class PredictionService:
             logger.error(f"Error finding best model: {str(e)}")
             raise
     
-    def predict(self, features: Dict[str, float], shop_type: str, bbox: str) -> Dict[str, float]:
-        """Получение предсказания"""
+    def predict(self, features: Dict[str, float], shop_type: str, bbox: str,
+                model_name: Optional[str] = None) -> Dict[str, float]:
+        """Получение предсказания. Если model_name не указан, модель выбирается по bbox"""
         try:
             # Находим подходящую модель
-            model_name = self.find_best_model(shop_type, bbox)
+            if not model_name:
+                model_name = self.find_best_model(shop_type, bbox)
             logger.info(f"Using model: {model_name}")
             
             # Загружаем модель и скейлер
@@ -72,7 +93,7 @@ class PredictionService:
             scaler_path = os.path.join(self.models_dir, f"scaler_{model_name}.joblib")
             
             if not os.path.exists(model_path) or not os.path.exists(scaler_path):
-                raise ValueError(f"Model or scaler not found: {model_name}")
+                raise ModelNotFoundError(f"Model or scaler not found: {model_name}")
             
             model = joblib.load(model_path)
             scaler = joblib.load(scaler_path)
@@ -94,6 +115,14 @@ class PredictionService:
             # Получаем предсказание
             activity_level = float(model.predict(X)[0])
             
+            # Начиная с версии 1.2 для случайного леса возвращаем 95% интервал
+            # по разбросу предсказаний отдельных деревьев
+            activity_interval = None
+            if isinstance(model, RandomForestRegressor):
+                tree_predictions = np.array([tree.predict(X)[0] for tree in model.estimators_])
+                lower, upper = np.percentile(tree_predictions, [2.5, 97.5])
+                activity_interval = [float(lower), float(upper)]
+            
             # Рассчитываем тренд на основе признаков
             trend = (
                 features['new_object_rate'] * 0.6 -
@@ -105,7 +134,7 @@ class PredictionService:
             predicted_new = int(total_objects * features['new_object_rate'])
             predicted_closed = int(total_objects * features['closure_rate'])
             
-            return {
+            result = {
                 "activity_level": activity_level,
                 "trend": trend,
                 "predicted_new": predicted_new,
@@ -113,6 +142,9 @@ class PredictionService:
                 "total_objects": total_objects,
                 "model_used": model_name
             }
+            if activity_interval is not None:
+                result["activity_interval"] = activity_interval
+            return result
             
         except Exception as e:
             logger.error(f"Error making prediction: {str(e)}")
@@ -154,54 +186,69 @@ def predict_endpoint():
     try:
         data = request.get_json()
         
+        # Проверка версии контракта (запросы без версии принимаются)
+        client_version = str(data.get('schema_version', SCHEMA_VERSION))
+        if client_version.split('.')[0] != SCHEMA_VERSION.split('.')[0]:
+            return jsonify({'error': f'Unsupported schema version: {client_version}, expected {SCHEMA_VERSION}'}), 400
+        
         # Валидация входных данных
         required_fields = ['bbox', 'shop_type', 'prediction_year', 'train_period']
         for field in required_fields:
             if field not in data:
                 return jsonify({'error': f'Missing required field: {field}'}), 400
         
-        # Получаем признаки из датасета
-        datasets_dir = "datasets"
-        
-        # Преобразуем формат периода из YYYY-YYYY в YYYYMMDD_to_YYYYMMDD
-        start_year, end_year = data['train_period'].split('-')
-        dataset_pattern = f"dataset_{data['shop_type']}_{start_year}0101_to_{end_year}1231.json"
-        dataset_files = glob.glob(os.path.join(datasets_dir, dataset_pattern))
-        
-        if not dataset_files:
-            return jsonify({'error': f'Dataset for shop type {data["shop_type"]} and period {data["train_period"]} not found'}), 404
-        
-        dataset_path = dataset_files[0]
-        with open(dataset_path, 'r') as f:
-            dataset = json.load(f)
-        
-        # Находим кластер по координатам
-        target_bbox = data['bbox']
-        cluster_features = None
-        
-        for cluster in dataset['clusters']:
-            if cluster['bbox'] == target_bbox:
-                # Берем последний год из данных кластера
-                last_year_data = cluster['data'][-1]['data'][0]
-                cluster_features = {
-                    'avg_area': last_year_data['avg_area'],
-                    'avg_dist_to_primary': last_year_data['avg_dist_to_primary'],
-                    'avg_dist_to_subway': last_year_data['avg_dist_to_subway'],
-                    'closure_rate': last_year_data['closure_rate'],
-                    'new_object_rate': last_year_data['new_object_rate'],
-                    'object_density': last_year_data['object_density'],
-                    'total_objects': last_year_data['total_objects']
-                }
-                break
-        
-        if cluster_features is None:
-            return jsonify({'error': f'Cluster with bbox {target_bbox} not found in dataset'}), 404
+        if data.get('features'):
+            # Начиная с версии 1.1 признаки считает osm_service тем же кодом, что и датасет
+            features = data['features']
+            missing = [name for name in FEATURE_NAMES if name not in features]
+            if missing:
+                return jsonify({'error': f'Missing features: {", ".join(missing)}'}), 400
+            cluster_features = {name: features[name] for name in FEATURE_NAMES}
+        else:
+            # Получаем признаки из датасета
+            datasets_dir = "datasets"
+        
+            # Преобразуем формат периода из YYYY-YYYY в YYYYMMDD_to_YYYYMMDD
+            start_year, end_year = data['train_period'].split('-')
+            dataset_pattern = f"dataset_{data['shop_type']}_{start_year}0101_to_{end_year}1231.json"
+            dataset_files = glob.glob(os.path.join(datasets_dir, dataset_pattern))
+        
+            if not dataset_files:
+                return jsonify({'error': f'Dataset for shop type {data["shop_type"]} and period {data["train_period"]} not found'}), 404
+        
+            dataset_path = dataset_files[0]
+            with open(dataset_path, 'r') as f:
+                dataset = json.load(f)
+        
+            # Находим кластер по координатам
+            target_bbox = data['bbox']
+            cluster_features = None
+        
+            for cluster in dataset['clusters']:
+                if cluster['bbox'] == target_bbox:
+                    # Берем последний год из данных кластера
+                    last_year_data = cluster['data'][-1]['data'][0]
+                    cluster_features = {
+                        'avg_area': last_year_data['avg_area'],
+                        'avg_dist_to_primary': last_year_data['avg_dist_to_primary'],
+                        'avg_dist_to_subway': last_year_data['avg_dist_to_subway'],
+                        'closure_rate': last_year_data['closure_rate'],
+                        'new_object_rate': last_year_data['new_object_rate'],
+                        'object_density': last_year_data['object_density'],
+                        'total_objects': last_year_data['total_objects']
+                    }
+                    break
+        
+            if cluster_features is None:
+                return jsonify({'error': f'Cluster with bbox {target_bbox} not found in dataset'}), 404
         
         # Получаем предсказание
-        result = prediction_service.predict(cluster_features, data['shop_type'], data['bbox'])
+        # С версии 1.3 можно запросить конкретную модель по имени (используется ансамблем)
+        result = prediction_service.predict(cluster_features, data['shop_type'], data['bbox'], data.get('model'))
         
         # Добавляем информацию о запросе
         result.update({
+            'schema_version': SCHEMA_VERSION,
             'bbox': data['bbox'],
             'shop_type': data['shop_type'],
             'prediction_year': data['prediction_year'],
@@ -210,6 +257,8 @@ def predict_endpoint():
         
         return jsonify(result), 200
         
+    except ModelNotFoundError as e:
+        return jsonify({'error': str(e)}), 404
     except ValueError as e:
         return jsonify({'error': str(e)}), 400
     except Exception as e:
@@ -2,7 +2,8 @@ import os
 import json
 import numpy as np
 import pandas as pd
-from sklearn.ensemble import RandomForestRegressor
+from sklearn.ensemble import RandomForestRegressor, GradientBoostingRegressor
+from sklearn.linear_model import LinearRegression, Ridge, Lasso
 from sklearn.preprocessing import StandardScaler
 from sklearn.metrics import mean_squared_error, r2_score
 import joblib
@@ -30,6 +31,45 @@ FEATURE_COLUMNS = [
     'total_objects'
 ]
 
+# Версия формата экспорта моделей для Go (inference.FormatVersion)
+EXPORT_FORMAT_VERSION = "1"
+
+
+def export_tree(tree) -> dict:
+    """Экспорт дерева sklearn в массивы узлов"""
+    t = tree.tree_
+    return {
+        'children_left': t.children_left.tolist(),
+        'children_right': t.children_right.tolist(),
+        'feature': t.feature.tolist(),
+        'threshold': t.threshold.tolist(),
+        'value': t.value[:, 0, 0].tolist()
+    }
+
+
+def export_estimator(model) -> dict:
+    """Экспорт модели в переносимый JSON формат"""
+    if isinstance(model, RandomForestRegressor):
+        return {
+            'type': 'random_forest',
+            'trees': [export_tree(tree) for tree in model.estimators_]
+        }
+    if isinstance(model, GradientBoostingRegressor):
+        return {
+            'type': 'gradient_boosting',
+            'learning_rate': float(model.learning_rate),
+            'init_value': float(np.ravel(model.init_.constant_)[0]),
+            'trees': [export_tree(tree) for tree in model.estimators_[:, 0]]
+        }
+    if isinstance(model, (LinearRegression, Ridge, Lasso)):
+        return {
+            'type': 'linear',
+            'coef': np.ravel(model.coef_).tolist(),
+            'intercept': float(np.ravel(model.intercept_)[0])
+        }
+    raise ValueError(f"Unsupported model type for export: {type(model).__name__}")
+
+
 class ModelTrainer:
     def __init__(self):
         # Переходим в корневую директорию проекта
@@ -153,6 +193,9 @@ class ModelTrainer:
             joblib.dump(model, model_path)
             joblib.dump(scaler, scaler_path)
             
+            # Экспорт в JSON для предсказаний в Go без Python
+            self.export_model(model, scaler, model_name, shop_type, year_suffix, bbox_suffix)
+            
             # Сохранение метрик
             self.metrics[model_name] = {
                 'mse': float(mse),
@@ -167,6 +210,28 @@ class ModelTrainer:
             logger.error(f"Error training model for {shop_type}: {str(e)}")
             raise
 
+    def export_model(self, model, scaler: StandardScaler, model_name: str,
+                     shop_type: str, year_suffix: str, bbox_suffix: str) -> None:
+        """Экспорт модели и скейлера в JSON (models/{model_name}.json)"""
+        exported = {
+            'format_version': EXPORT_FORMAT_VERSION,
+            'name': model_name,
+            'shop_type': shop_type,
+            'train_period': year_suffix,
+            'bbox': bbox_suffix.replace('_', ','),
+            'feature_names': FEATURE_COLUMNS,
+            'scaler': {
+                'mean': scaler.mean_.tolist(),
+                'scale': scaler.scale_.tolist()
+            },
+            'model': export_estimator(model)
+        }
+        
+        export_path = os.path.join(self.models_dir, f"{model_name}.json")
+        with open(export_path, 'w') as f:
+            json.dump(exported, f)
+        logger.info(f"Model {model_name} exported to {export_path}")
+
     def train_all_models(self) -> None:
         """Обучение моделей для всех датасетов"""
         try:
@@ -0,0 +1,110 @@
+// Команда backtest проверяет точность предсказаний на истории и печатает отчет в JSON.
+//
+//	go run ./cmd/backtest -bbox 55.70,37.50,55.80,37.70 -categories cafe,restaurant -cutoff 2022-01-01
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"osm_service/internal/core"
+	"osm_service/internal/domain/model"
+	"osm_service/internal/domain/repository"
+	"osm_service/internal/infrastructure/mlclient"
+	"strings"
+	"time"
+)
+
+func main() {
+	bbox := flag.String("bbox", "", "area \"minLat,minLon,maxLat,maxLon\"")
+	categories := flag.String("categories", "", "comma-separated shop types")
+	cutoff := flag.String("cutoff", "", "cutoff date in format 2006-01-02")
+	clusterSize := flag.Float64("cluster-size", 1, "cluster size in kilometers")
+	trainYears := flag.Int("train-years", 3, "years of history before the cutoff")
+	horizonYears := flag.Int("horizon-years", 1, "years after the cutoff to compare with")
+	output := flag.String("out", "", "output file (stdout by default)")
+	overpassSlots := flag.Int("overpass-slots", repository.DefaultOverpassSlots, "concurrent Overpass queries")
+	flag.Parse()
+
+	bounds, err := model.ParseBounds(*bbox)
+	if err != nil {
+		log.Fatalf("Invalid bbox: %v", err)
+	}
+	cutoffDate, err := time.Parse("2006-01-02", *cutoff)
+	if err != nil {
+		log.Fatalf("Invalid cutoff date: %v", err)
+	}
+	shopTypes := splitCategories(*categories)
+	if len(shopTypes) == 0 {
+		log.Fatalf("At least one category is required in -categories")
+	}
+
+	overpassAPI := os.Getenv("OVERPASS_URL")
+	if overpassAPI == "" {
+		overpassAPI = "https://maps.mail.ru/osm/tools/overpass/api/interpreter"
+	}
+	mlServiceURL := os.Getenv("ML_SERVICE_URL")
+	if mlServiceURL == "" {
+		mlServiceURL = "http://localhost:5000"
+	}
+
+	// База данных для проверки не нужна: признаки и факты берутся из Overpass
+	overpassRepo := repository.NewOverpassRepository(overpassAPI, 120*time.Second, *overpassSlots)
+	service := core.NewPredictionService(
+		*overpassRepo,
+		repository.PostGISRepository{},
+		mlclient.NewHTTPMLClient(mlServiceURL, mlclient.DefaultConfig()),
+		nil,
+		false,
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := service.Backtest(ctx, model.BacktestOptions{
+		Bounds:       bounds,
+		Categories:   shopTypes,
+		ClusterSize:  *clusterSize,
+		Cutoff:       cutoffDate,
+		TrainYears:   *trainYears,
+		HorizonYears: *horizonYears,
+	})
+	if err != nil {
+		log.Fatalf("Backtest failed: %v", err)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			log.Fatalf("Failed to create output file: %v", err)
+		}
+		defer out.Close()
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+
+	for _, category := range result.Categories {
+		log.Printf("%s: %d clusters (%d failed), predicted_new MAE=%.2f RMSE=%.2f",
+			category.Category, category.Clusters, category.Failed,
+			category.Metrics.PredictedNew.MAE, category.Metrics.PredictedNew.RMSE)
+	}
+}
+
+// splitCategories разбирает список категорий через запятую, пропуская пустые
+func splitCategories(list string) []string {
+	var categories []string
+	for _, category := range strings.Split(list, ",") {
+		if category = strings.TrimSpace(category); category != "" {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
@@ -6,8 +6,13 @@ import (
 	"os"
 	"osm_service/internal/api"
 	"osm_service/internal/core"
+	"osm_service/internal/domain/model"
 	"osm_service/internal/domain/repository"
+	"osm_service/internal/inference"
 	"osm_service/internal/infrastructure/mlclient"
+	"osm_service/internal/jobs"
+	"path/filepath"
+	"strconv"
 	"time"
 )
 
@@ -30,8 +35,8 @@ func main() {
 
 	// Инициализация репозиториев
 	postgresRepo := repository.NewPostgresRepository(postgresURL)
-	overpassRepo := repository.NewOverpassRepository(overpassAPI, 120*time.Second)
-	mlClient := mlclient.NewHTTPMLClient(mlServiceURL)
+	overpassRepo := repository.NewOverpassRepository(overpassAPI, 120*time.Second, intEnv("OVERPASS_SLOTS", repository.DefaultOverpassSlots))
+	mlClient := mlclient.NewHTTPMLClient(mlServiceURL, mlClientConfig())
 
 	// Создание сервиса предсказаний
 	predictionService := core.NewPredictionService(
@@ -42,13 +47,81 @@ func main() {
 		false,
 	)
 
+	// Прогноз ряда в Go используется, когда ML сервис не может ответить
+	if method := os.Getenv("FORECAST_METHOD"); method != "" {
+		predictionService.SetForecastMethod(method)
+	}
+	if os.Getenv("FORECAST_BENCHMARK") == "true" {
+		predictionService.EnableForecastBenchmark()
+	}
+
+	// Предсказания моделями, экспортированными в JSON, без обращения к ML сервису.
+	// Если задан MODELS_DIR, модели загружаются и в режиме remote — для объяснений предсказаний
+	mode := os.Getenv("INFERENCE_MODE")
+	if mode == "" {
+		mode = core.InferenceRemote
+	}
+	modelsDir := os.Getenv("MODELS_DIR")
+	if mode != core.InferenceRemote || modelsDir != "" {
+		if modelsDir == "" {
+			modelsDir = filepath.Join("..", "models")
+		}
+		store, err := inference.NewStore(modelsDir)
+		if err != nil {
+			log.Fatalf("Failed to load exported models: %v", err)
+		}
+		if err := predictionService.SetLocalInference(store, mode); err != nil {
+			log.Fatalf("Failed to configure inference: %v", err)
+		}
+	}
+
+	// Реестр моделей и журнал предсказаний в PostgreSQL
+	predictionService.SetModelRegistry(repository.NewPostgresModelRegistry(postgresRepo.DB))
+	predictionService.SetPredictionLog(repository.NewPostgresPredictionLog(postgresRepo.DB))
+
+	// Теневая или canary-выкатка модели-кандидата
+	if candidate := os.Getenv("ROLLOUT_MODEL"); candidate != "" {
+		rollout := model.Rollout{
+			Mode:      os.Getenv("ROLLOUT_MODE"),
+			Candidate: candidate,
+			Percent:   floatEnv("ROLLOUT_PERCENT", 10),
+		}
+		if rollout.Mode == "" {
+			rollout.Mode = model.RolloutShadow
+		}
+		if err := predictionService.SetRollout(rollout); err != nil {
+			log.Fatalf("Failed to configure rollout: %v", err)
+		}
+		log.Printf("Rollout of model %s in %s mode", rollout.Candidate, rollout.Mode)
+	}
+
+	// Кластеры датасета обрабатываются параллельно, не больше OVERPASS_SLOTS одновременно
+	predictionService.SetDatasetConcurrency(intEnv("DATASET_CONCURRENCY", 0))
+
+	// Области пакетного предсказания тоже обрабатываются в пределах слотов Overpass
+	predictionService.SetBatchConcurrency(intEnv("BATCH_CONCURRENCY", 0))
+
+	// Готовые кластеры сохраняются, чтобы прерванное построение датасета можно было продолжить
+	checkpointDir := os.Getenv("DATASET_CHECKPOINT_DIR")
+	if checkpointDir == "" {
+		checkpointDir = filepath.Join("..", "datasets", "checkpoints")
+	}
+	predictionService.SetDatasetCheckpoints(repository.NewFileDatasetCheckpoints(checkpointDir))
+
+	// Задачи построения датасетов выполняются в фоне, завершенные хранятся JOB_TTL
+	jobManager := jobs.NewManager(intEnv("JOB_WORKERS", 1))
+	jobManager.SetTTL(durationEnv("JOB_TTL", jobs.DefaultTTL))
+
 	// Настройка HTTP-обработчиков
-	handler := api.NewHandler(predictionService)
+	handler := api.NewHandler(predictionService, jobManager)
 
-	// Добавляем middleware для логирования
-	http.HandleFunc("/api/predict", logMiddleware(handler.Predict))
-	http.HandleFunc("/api/training", logMiddleware(handler.Training))
-	http.HandleFunc("/api/models", logMiddleware(handler.GetModels))
+	// Маршруты API: текущая версия под /api/v1 и те же обработчики под /api
+	// для существующих клиентов. Middleware логирует каждый запрос, ответы 404 и 405
+	// на запросы без маршрута отправляются в формате ErrorResponse
+	mux := http.NewServeMux()
+	routes := handler.Routes()
+	api.Register(mux, api.APIVersionPrefix, routes, logMiddleware)
+	api.Register(mux, api.LegacyPrefix, routes, logMiddleware)
 
 	// Запуск сервера
 	port := os.Getenv("PORT")
@@ -57,11 +130,64 @@ func main() {
 	}
 
 	log.Printf("Server starting on port %s", port)
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
+	if err := http.ListenAndServe(":"+port, api.WithErrorResponses(mux)); err != nil {
 		log.Fatalf("Server failed to start: %v", err)
 	}
 }
 
+// mlClientConfig читает настройки ML клиента из окружения
+func mlClientConfig() mlclient.Config {
+	config := mlclient.DefaultConfig()
+	config.Timeout = durationEnv("ML_TIMEOUT", config.Timeout)
+	config.MaxRetries = intEnv("ML_MAX_RETRIES", config.MaxRetries)
+	config.RetryBackoff = durationEnv("ML_RETRY_BACKOFF", config.RetryBackoff)
+	config.FailureThreshold = intEnv("ML_BREAKER_THRESHOLD", config.FailureThreshold)
+	config.OpenTimeout = durationEnv("ML_BREAKER_COOLDOWN", config.OpenTimeout)
+	return config
+}
+
+// durationEnv возвращает длительность из переменной окружения (например, "30s")
+func durationEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using %v: %v", name, value, fallback, err)
+		return fallback
+	}
+	return parsed
+}
+
+// intEnv возвращает целое число из переменной окружения
+func intEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using %d: %v", name, value, fallback, err)
+		return fallback
+	}
+	return parsed
+}
+
+// floatEnv возвращает число с плавающей точкой из переменной окружения
+func floatEnv(name string, fallback float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using %v: %v", name, value, fallback, err)
+		return fallback
+	}
+	return parsed
+}
+
 // logMiddleware добавляет логирование запросов
 func logMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -94,3 +220,8 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap дает http.ResponseController доступ к исходному ResponseWriter (Flush для SSE)
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"osm_service/internal/core"
+	"osm_service/internal/domain/model"
+	"time"
+)
+
+type ChangePointRequest struct {
+	BBox                    string  `json:"bbox"`                     // Area coordinates
+	ShopType                string  `json:"shop_type"`                // Type of objects to analyze
+	StartDate               string  `json:"start_date"`               // Start date in format "2006-01-02"
+	EndDate                 string  `json:"end_date"`                 // End date in format "2006-01-02"
+	Penalty                 float64 `json:"penalty"`                  // Optional PELT penalty, estimated from data if zero
+	MinSegment              int     `json:"min_segment"`              // Optional minimal segment length in periods
+	CorrelateInfrastructure bool    `json:"correlate_infrastructure"` // Compare subway stations around each change point
+}
+
+type ChangePointResponse struct {
+	BBox         string              `json:"bbox"`
+	ShopType     string              `json:"shop_type"`
+	ChangePoints []model.ChangePoint `json:"change_points"`
+}
+
+// analysisArea — проверенные область и период запроса анализа
+type analysisArea struct {
+	Bounds    model.Bounds
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// validateAnalysisArea проверяет общие поля запросов анализа и добавляет
+// все ошибки в validation
+func validateAnalysisArea(validation *core.ValidationError, bbox, shopType, start, end string) analysisArea {
+	var area analysisArea
+	bounds, err := model.ParseBounds(bbox)
+	if bbox == "" {
+		validation.Add("bbox", "is required")
+	} else if err != nil {
+		validation.Add("bbox", "%v", err)
+	}
+	area.Bounds = bounds
+	if shopType == "" {
+		validation.Add("shop_type", "is required")
+	}
+
+	startDate, startErr := time.Parse("2006-01-02", start)
+	if startErr != nil {
+		validation.Add("start_date", "invalid format, use YYYY-MM-DD")
+	}
+	endDate, endErr := time.Parse("2006-01-02", end)
+	if endErr != nil {
+		validation.Add("end_date", "invalid format, use YYYY-MM-DD")
+	}
+	if startErr == nil && endErr == nil && endDate.Before(startDate) {
+		validation.Add("end_date", "must be after start_date")
+	}
+	area.StartDate = startDate
+	area.EndDate = endDate
+	return area
+}
+
+// Validate проверяет запрос и возвращает разобранные область и период
+func (req ChangePointRequest) Validate() (analysisArea, error) {
+	var validation core.ValidationError
+	area := validateAnalysisArea(&validation, req.BBox, req.ShopType, req.StartDate, req.EndDate)
+	if req.Penalty < 0 {
+		validation.Add("penalty", "must not be negative, got %v", req.Penalty)
+	}
+	if req.MinSegment < 0 {
+		validation.Add("min_segment", "must not be negative, got %d", req.MinSegment)
+	}
+	return area, validation.Err()
+}
+
+// ChangePoints находит моменты, когда количество объектов в области сменило уровень
+func (h *Handler) ChangePoints(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	var req ChangePointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r, err)
+		return
+	}
+
+	area, err := req.Validate()
+	if err != nil {
+		writeServiceError(w, r, err, "validating change point request")
+		return
+	}
+
+	detector := core.ChangePointDetector{
+		Penalty:    req.Penalty,
+		MinSegment: req.MinSegment,
+	}
+
+	points, err := h.service.DetectChangePoints(r.Context(), area.Bounds, area.StartDate, area.EndDate, req.ShopType, detector, req.CorrelateInfrastructure)
+	if err != nil {
+		writeServiceError(w, r, err, "detecting change points")
+		return
+	}
+
+	response := ChangePointResponse{
+		BBox:         req.BBox,
+		ShopType:     req.ShopType,
+		ChangePoints: points,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type ImpactRequest struct {
+	BBox         string  `json:"bbox"`          // Area coordinates
+	ShopType     string  `json:"shop_type"`     // Type of objects to analyze
+	StartDate    string  `json:"start_date"`    // Start date in format "2006-01-02"
+	EndDate      string  `json:"end_date"`      // End date in format "2006-01-02"
+	RingRadius   float64 `json:"ring_radius"`   // Optional radius of the treated ring in meters
+	ControlInner float64 `json:"control_inner"` // Optional inner radius of the control ring in meters
+	ControlOuter float64 `json:"control_outer"` // Optional outer radius of the control ring in meters
+}
+
+type ImpactResponse struct {
+	BBox     string                 `json:"bbox"`
+	ShopType string                 `json:"shop_type"`
+	Events   []model.ImpactEstimate `json:"events"`
+}
+
+// Validate проверяет запрос и возвращает разобранные область и период.
+// Нулевые радиусы заменяются значениями по умолчанию (см. core.ImpactOptions).
+func (req ImpactRequest) Validate() (analysisArea, error) {
+	var validation core.ValidationError
+	area := validateAnalysisArea(&validation, req.BBox, req.ShopType, req.StartDate, req.EndDate)
+	if req.RingRadius < 0 {
+		validation.Add("ring_radius", "must not be negative, got %v", req.RingRadius)
+	}
+	if req.ControlInner < 0 {
+		validation.Add("control_inner", "must not be negative, got %v", req.ControlInner)
+	}
+	if req.ControlOuter < 0 {
+		validation.Add("control_outer", "must not be negative, got %v", req.ControlOuter)
+	}
+	return area, validation.Err()
+}
+
+// Impact оценивает влияние новых станций метро и главных дорог на количество объектов
+func (h *Handler) Impact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	var req ImpactRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r, err)
+		return
+	}
+
+	area, err := req.Validate()
+	if err != nil {
+		writeServiceError(w, r, err, "validating impact request")
+		return
+	}
+
+	opts := core.ImpactOptions{
+		RingRadius:   req.RingRadius,
+		ControlInner: req.ControlInner,
+		ControlOuter: req.ControlOuter,
+	}
+
+	estimates, err := h.service.AnalyzeImpact(r.Context(), area.Bounds, area.StartDate, area.EndDate, req.ShopType, opts)
+	if err != nil {
+		writeServiceError(w, r, err, "analyzing impact")
+		return
+	}
+
+	response := ImpactResponse{
+		BBox:     req.BBox,
+		ShopType: req.ShopType,
+		Events:   estimates,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
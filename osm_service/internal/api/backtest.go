@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"osm_service/internal/core"
+	"osm_service/internal/domain/model"
+	"time"
+)
+
+// maxBacktestChecks ограничивает число проверок кластер × категория в одном запросе:
+// бэктест выполняется синхронно, пока клиент ждет ответа. Большие области
+// проверяются командой cmd/backtest.
+const maxBacktestChecks = 200
+
+type BacktestRequest struct {
+	BBox         string   `json:"bbox"`          // Area coordinates
+	ShopTypes    []string `json:"shop_types"`    // Categories to check
+	ClusterSize  float64  `json:"cluster_size"`  // Size of cluster in kilometers
+	CutoffDate   string   `json:"cutoff_date"`   // Features use only data up to this date, format "2006-01-02"
+	TrainYears   int      `json:"train_years"`   // Years of history before the cutoff, 3 by default
+	HorizonYears int      `json:"horizon_years"` // Years after the cutoff to compare with, 1 by default
+}
+
+// Validate проверяет запрос и возвращает параметры бэктеста. Число кластеров
+// ограничено так, чтобы проверок кластер × категория было не больше maxBacktestChecks.
+func (req BacktestRequest) Validate() (model.BacktestOptions, error) {
+	var validation core.ValidationError
+
+	bounds, boundsErr := model.ParseBounds(req.BBox)
+	if req.BBox == "" {
+		validation.Add("bbox", "is required")
+	} else if boundsErr != nil {
+		validation.Add("bbox", "%v", boundsErr)
+	}
+	if len(req.ShopTypes) == 0 {
+		validation.Add("shop_types", "is required")
+	}
+
+	// Некорректный bbox дает пустые границы и не учитывается
+	maxClusters := maxBacktestChecks / max(len(req.ShopTypes), 1)
+	if err := core.ValidateClusterSize(bounds, req.ClusterSize, maxClusters); err != nil {
+		validation.Add("cluster_size", "%v", err)
+	}
+
+	cutoff, err := time.Parse("2006-01-02", req.CutoffDate)
+	if err != nil {
+		validation.Add("cutoff_date", "invalid format, use YYYY-MM-DD")
+	}
+	if req.TrainYears < 0 {
+		validation.Add("train_years", "must not be negative, got %d", req.TrainYears)
+	}
+	if req.HorizonYears < 0 {
+		validation.Add("horizon_years", "must not be negative, got %d", req.HorizonYears)
+	}
+
+	return model.BacktestOptions{
+		Bounds:       bounds,
+		Categories:   req.ShopTypes,
+		ClusterSize:  req.ClusterSize,
+		Cutoff:       cutoff,
+		TrainYears:   req.TrainYears,
+		HorizonYears: req.HorizonYears,
+	}, validation.Err()
+}
+
+// Backtest проверяет точность предсказаний на истории. Запрос выполняется синхронно
+// и отменяется, если клиент отключился, поэтому число проверок ограничено maxBacktestChecks.
+func (h *Handler) Backtest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	var req BacktestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r, err)
+		return
+	}
+
+	opts, err := req.Validate()
+	if err != nil {
+		writeServiceError(w, r, err, "validating backtest request")
+		return
+	}
+
+	result, err := h.service.Backtest(r.Context(), opts)
+	if err != nil {
+		writeServiceError(w, r, err, "running backtest")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"osm_service/internal/core"
+	"osm_service/internal/domain/model"
+	"strconv"
+)
+
+// ndjsonMediaType — тип ответа пакетного предсказания: по объекту JSON на строку
+const ndjsonMediaType = "application/x-ndjson"
+
+// BatchArea — область пакетного предсказания
+type BatchArea struct {
+	ID   string `json:"id,omitempty"` // Client identifier, returned with the result
+	BBox string `json:"bbox"`
+}
+
+type BatchPredictRequest struct {
+	Areas          []BatchArea `json:"areas,omitempty"`        // Areas to score
+	BBox           string      `json:"bbox,omitempty"`         // Or an area to split into clusters
+	ClusterSize    float64     `json:"cluster_size,omitempty"` // Size of cluster in km when bbox is set
+	ShopType       string      `json:"shop_type"`
+	PredictionYear string      `json:"prediction_year"`
+	TrainPeriod    string      `json:"train_period,omitempty"`
+}
+
+// BatchPredictResult — строка ответа пакетного предсказания: предсказание области или ошибка
+type BatchPredictResult struct {
+	Index      int               `json:"index"`
+	ID         string            `json:"id,omitempty"`
+	BBox       string            `json:"bbox"`
+	Prediction *model.Prediction `json:"prediction,omitempty"`
+	Error      *ErrorResponse    `json:"error,omitempty"`
+}
+
+// Validate проверяет запрос и возвращает области пакета: переданные в areas
+// или кластеры bbox размером cluster_size, как при построении датасета.
+// bbox отдельных областей не проверяется, см. PredictBatch.
+func (req BatchPredictRequest) Validate() ([]BatchArea, error) {
+	var validation core.ValidationError
+	if req.ShopType == "" {
+		validation.Add("shop_type", "is required")
+	}
+	if req.PredictionYear == "" {
+		validation.Add("prediction_year", "is required")
+	} else if _, err := strconv.Atoi(req.PredictionYear); err != nil {
+		validation.Add("prediction_year", "must be a year, got %q", req.PredictionYear)
+	}
+
+	var areas []BatchArea
+	switch {
+	case len(req.Areas) > 0 && req.BBox != "":
+		validation.Add("areas", "use either areas or bbox with cluster_size")
+	case len(req.Areas) > 0:
+		if len(req.Areas) > core.MaxBatchAreas {
+			validation.Add("areas", "at most %d areas per batch, got %d", core.MaxBatchAreas, len(req.Areas))
+			break
+		}
+		// Некорректный bbox области не отклоняет пакет: ошибка вернется в строке области
+		areas = req.Areas
+	case req.BBox != "":
+		bounds, err := model.ParseBounds(req.BBox)
+		if err != nil {
+			validation.Add("bbox", "%v", err)
+			break
+		}
+		if err := core.ValidateClusterSize(bounds, req.ClusterSize, core.MaxBatchAreas); err != nil {
+			validation.Add("cluster_size", "%v", err)
+			break
+		}
+		for _, cluster := range core.GenerateClusters(bounds, req.ClusterSize) {
+			areas = append(areas, BatchArea{
+				BBox: fmt.Sprintf("%f,%f,%f,%f", cluster.MinLat, cluster.MinLon, cluster.MaxLat, cluster.MaxLon),
+			})
+		}
+	default:
+		validation.Add("areas", "areas or bbox with cluster_size is required")
+	}
+
+	return areas, validation.Err()
+}
+
+// PredictBatch получает предсказания для многих областей и отправляет их потоком NDJSON
+// по мере готовности: по строке BatchPredictResult на область, в порядке завершения.
+// Ошибка области, в том числе некорректный bbox, возвращается в ее строке и не прерывает пакет.
+func (h *Handler) PredictBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	var req BatchPredictRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r, err)
+		return
+	}
+
+	areas, err := req.Validate()
+	if err != nil {
+		writeServiceError(w, r, err, "validating batch prediction request")
+		return
+	}
+
+	w.Header().Set("Content-Type", ndjsonMediaType)
+	w.Header().Set("X-Batch-Size", strconv.Itoa(len(areas)))
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	rc.Flush()
+
+	bboxes := make([]string, len(areas))
+	for i, area := range areas {
+		bboxes[i] = area.BBox
+	}
+
+	encoder := json.NewEncoder(w)
+	h.service.PredictBatch(r.Context(), bboxes, req.ShopType, req.PredictionYear, req.TrainPeriod,
+		func(index int, prediction *model.Prediction, err error) {
+			result := BatchPredictResult{
+				Index:      index,
+				ID:         areas[index].ID,
+				BBox:       areas[index].BBox,
+				Prediction: prediction,
+			}
+			if err != nil {
+				_, response := serviceError(r, err, "getting prediction")
+				result.Prediction = nil
+				result.Error = &response
+			}
+			encoder.Encode(result)
+			rc.Flush()
+		})
+}
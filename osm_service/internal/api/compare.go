@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"osm_service/internal/core"
+	"osm_service/internal/domain/model"
+	"strconv"
+)
+
+type CompareRequest struct {
+	Areas          []BatchArea `json:"areas"` // Areas to compare, ids label them in the response
+	ShopType       string      `json:"shop_type"`
+	PredictionYear string      `json:"prediction_year"`
+	TrainPeriod    string      `json:"train_period,omitempty"`
+}
+
+type CompareResponse struct {
+	ShopType       string         `json:"shop_type"`
+	PredictionYear string         `json:"prediction_year"`
+	Areas          []ComparedArea `json:"areas"` // In request order; rank orders model predictions by activity_level, fallback forecasts are unranked
+}
+
+// ComparedArea — область в ответе сравнения; error заполнен, если для нее нет предсказания
+type ComparedArea struct {
+	model.ComparedArea
+	Error *ErrorResponse `json:"error,omitempty"`
+}
+
+func (req CompareRequest) Validate() error {
+	var validation core.ValidationError
+	if len(req.Areas) < core.MinCompareAreas || len(req.Areas) > core.MaxCompareAreas {
+		validation.Add("areas", "must contain from %d to %d areas, got %d", core.MinCompareAreas, core.MaxCompareAreas, len(req.Areas))
+	}
+	for i, area := range req.Areas {
+		if _, err := model.ParseBounds(area.BBox); err != nil {
+			validation.Add(fmt.Sprintf("areas[%d].bbox", i), "%v", err)
+		}
+	}
+	if req.ShopType == "" {
+		validation.Add("shop_type", "is required")
+	}
+	if req.PredictionYear == "" {
+		validation.Add("prediction_year", "is required")
+	} else if _, err := strconv.Atoi(req.PredictionYear); err != nil {
+		validation.Add("prediction_year", "must be a year, got %q", req.PredictionYear)
+	}
+	return validation.Err()
+}
+
+// Compare сравнивает области одной категории: признаки, предсказания и место каждой
+// области по уровню активности. Если ни для одной области нет предсказания,
+// возвращается ошибка первой области.
+func (h *Handler) Compare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	var req CompareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeServiceError(w, r, err, "validating comparison request")
+		return
+	}
+
+	areas := make([]model.ComparedArea, len(req.Areas))
+	for i, area := range req.Areas {
+		areas[i] = model.ComparedArea{ID: area.ID, BBox: area.BBox}
+	}
+	compared := h.service.CompareAreas(r.Context(), areas, req.ShopType, req.PredictionYear, req.TrainPeriod)
+
+	response := CompareResponse{
+		ShopType:       req.ShopType,
+		PredictionYear: req.PredictionYear,
+		Areas:          make([]ComparedArea, len(compared)),
+	}
+	var firstErr error
+	failed := 0
+	for i, area := range compared {
+		response.Areas[i] = ComparedArea{ComparedArea: area}
+		if area.Err != nil {
+			_, body := serviceError(r, area.Err, "comparing areas")
+			response.Areas[i].Error = &body
+			failed++
+			if firstErr == nil {
+				firstErr = area.Err
+			}
+		}
+	}
+	if failed == len(compared) {
+		writeServiceError(w, r, firstErr, "comparing areas")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"osm_service/internal/domain/model"
+	"path/filepath"
+	"strings"
+)
+
+// datasetsDir — каталог датасетов рядом с сервисом (на уровне Dockerfile)
+var datasetsDir = filepath.Join("..", "datasets")
+
+// Dataset отдает файл датасета по имени: /datasets/{name}. С заголовком
+// Accept: application/geo+json или параметром format=geojson кластеры
+// возвращаются полигонами GeoJSON.
+func (h *Handler) Dataset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	name := r.PathValue("name")
+	if !validDatasetName(name) {
+		writeValidationError(w, r, "name", datasetNameMessage)
+		return
+	}
+
+	data, err := os.ReadFile(filepath.Join(datasetsDir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		writeDatasetNotFound(w, r)
+		return
+	}
+	if err != nil {
+		writeServiceError(w, r, err, "reading dataset")
+		return
+	}
+
+	if !wantsGeoJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+		return
+	}
+
+	var dataset model.Dataset
+	if err := json.Unmarshal(data, &dataset); err != nil {
+		writeServiceError(w, r, err, "decoding dataset")
+		return
+	}
+	collection, err := datasetGeoJSON(dataset)
+	if err != nil {
+		writeServiceError(w, r, err, "converting dataset")
+		return
+	}
+	writeGeoJSON(w, collection)
+}
+
+// datasetNameMessage описывает ожидаемое имя файла датасета
+const datasetNameMessage = "expected a dataset file name like dataset_cafe_20190101_to_20230101.json"
+
+// validDatasetName допускает только имена файлов датасетов в datasetsDir, без путей
+func validDatasetName(name string) bool {
+	return strings.HasPrefix(name, "dataset_") && strings.HasSuffix(name, ".json") && filepath.Base(name) == name
+}
+
+func writeDatasetNotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, CodeNotFound, "Dataset not found", nil)
+}
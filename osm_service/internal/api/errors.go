@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"osm_service/internal/core"
+	"osm_service/internal/domain/model"
+	"osm_service/internal/domain/repository"
+)
+
+// Коды ошибок в ответах API
+const (
+	CodeValidation       = "validation_error"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeUnavailable      = "upstream_unavailable"
+	CodeTimeout          = "upstream_timeout"
+	CodeNotConfigured    = "not_configured"
+	CodeInternal         = "internal_error"
+)
+
+// requestIDHeader — заголовок с идентификатором запроса; присланный клиентом сохраняется
+const requestIDHeader = "X-Request-ID"
+
+// ErrorResponse — тело ответа с ошибкой
+type ErrorResponse struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id"`
+}
+
+// withRequestID присваивает запросу идентификатор и возвращает его в заголовке X-Request-ID
+func withRequestID(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = core.NewRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next(w, r.WithContext(core.WithRequestID(r.Context(), id)))
+	}
+}
+
+// requestID возвращает идентификатор запроса
+func requestID(r *http.Request) string {
+	return core.RequestID(r.Context())
+}
+
+// writeError отправляет ошибку в формате ErrorResponse
+func writeError(w http.ResponseWriter, r *http.Request, status int, code string, message string, details any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: requestID(r),
+	})
+}
+
+// writeValidationError отправляет ошибку проверки одного поля запроса
+func writeValidationError(w http.ResponseWriter, r *http.Request, field string, message string) {
+	writeError(w, r, http.StatusBadRequest, CodeValidation, "Invalid request", []core.FieldError{{Field: field, Message: message}})
+}
+
+// writeMethodNotAllowed отвечает на запрос неподдерживаемым методом
+func writeMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed", nil)
+}
+
+// writeInvalidBody отвечает на тело запроса, которое не удалось разобрать
+func writeInvalidBody(w http.ResponseWriter, r *http.Request, err error) {
+	writeError(w, r, http.StatusBadRequest, CodeValidation, "Invalid request body", err.Error())
+}
+
+// writeServiceError выбирает статус по категории ошибки сервиса. Текст внутренних
+// ошибок не возвращается клиенту, а пишется в лог вместе с идентификатором запроса.
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error, action string) {
+	status, response := serviceError(r, err, action)
+	writeError(w, r, status, response.Code, response.Message, response.Details)
+}
+
+// serviceError возвращает статус и тело ответа для ошибки сервиса, см. writeServiceError
+func serviceError(r *http.Request, err error, action string) (int, ErrorResponse) {
+	response := func(code, message string, details any) ErrorResponse {
+		return ErrorResponse{Code: code, Message: message, Details: details, RequestID: requestID(r)}
+	}
+
+	var validation *core.ValidationError
+	if errors.As(err, &validation) {
+		return http.StatusBadRequest, response(CodeValidation, "Invalid request", validation.Fields)
+	}
+
+	switch core.ErrorKind(err) {
+	case core.ErrValidation:
+		return http.StatusBadRequest, response(CodeValidation, err.Error(), nil)
+	case core.ErrNotFound:
+		return http.StatusNotFound, response(CodeNotFound, err.Error(), nil)
+	case core.ErrConflict:
+		return http.StatusConflict, response(CodeConflict, err.Error(), nil)
+	case core.ErrNotConfigured:
+		return http.StatusNotImplemented, response(CodeNotConfigured, err.Error(), nil)
+	case core.ErrTimeout:
+		log.Printf("Request %s: %s: %v", requestID(r), action, err)
+		return http.StatusGatewayTimeout, response(CodeTimeout, "Upstream service timed out, try again later", nil)
+	case core.ErrUnavailable:
+		log.Printf("Request %s: %s: %v", requestID(r), action, err)
+		message := "Upstream service is unavailable, try again later"
+		switch {
+		case errors.Is(err, model.ErrMLServiceUnavailable):
+			message = "ML service is unavailable, try again later"
+		case errors.Is(err, repository.ErrOverpassUnavailable):
+			message = "Overpass API is unavailable, try again later"
+		}
+		return http.StatusServiceUnavailable, response(CodeUnavailable, message, nil)
+	default:
+		log.Printf("Request %s: %s: %v", requestID(r), action, err)
+		return http.StatusInternalServerError, response(CodeInternal, "Internal error while "+action, nil)
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"osm_service/internal/domain/model"
+)
+
+// CompareModels возвращает точность моделей по фактическим данным и расхождение
+// теневой модели с основной
+func (h *Handler) CompareModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	comparison, err := h.service.CompareModels(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err, "comparing models")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comparison)
+}
+
+// GroundTruth принимает фактические открытия и закрытия объектов за год
+func (h *Handler) GroundTruth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	var req model.GroundTruth
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r, err)
+		return
+	}
+
+	err := h.service.SaveGroundTruth(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, r, err, "saving ground truth")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"osm_service/internal/domain/model"
+	"osm_service/internal/geojson"
+	"strconv"
+	"strings"
+)
+
+// Значения атрибута feature_type в выгрузках GeoJSON
+const (
+	geoFeatureArea    = "area"
+	geoFeatureHotspot = "hotspot"
+	geoFeatureCluster = "cluster"
+)
+
+// wantsGeoJSON сообщает, что клиент запросил GeoJSON заголовком Accept или параметром format=geojson
+func wantsGeoJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), geojson.MediaType) || r.URL.Query().Get("format") == "geojson"
+}
+
+// writeGeoJSON отправляет набор объектов GeoJSON
+func writeGeoJSON(w http.ResponseWriter, collection geojson.FeatureCollection) {
+	w.Header().Set("Content-Type", geojson.MediaType)
+	json.NewEncoder(w).Encode(collection)
+}
+
+// predictionGeoJSON представляет предсказание полигоном области с показателями
+// предсказания в атрибутах и точками горячих зон
+func predictionGeoJSON(req PredictRequest, bounds model.Bounds, prediction *model.Prediction) geojson.FeatureCollection {
+	properties := map[string]any{
+		"feature_type":     geoFeatureArea,
+		"bbox":             req.BBox,
+		"shop_type":        req.ShopType,
+		"prediction_year":  req.PredictionYear,
+		"model_used":       prediction.ModelUsed,
+		"activity_level":   prediction.ActivityLevel,
+		"trend":            prediction.Trend,
+		"predicted_new":    prediction.PredictedNew,
+		"predicted_closed": prediction.PredictedClosed,
+		"total_objects":    prediction.TotalObjects,
+		"fallback":         prediction.Fallback,
+	}
+	if intervals := prediction.Intervals; intervals != nil {
+		addInterval(properties, "activity_level", intervals.ActivityLevel)
+		addInterval(properties, "predicted_new", intervals.PredictedNew)
+		addInterval(properties, "predicted_closed", intervals.PredictedClosed)
+	}
+	if prediction.DataQuality != nil {
+		properties["data_quality_score"] = prediction.DataQuality.Score
+	}
+
+	features := []geojson.Feature{geojson.NewFeature(geojson.BoundsPolygon(bounds), properties)}
+	for _, hotspot := range prediction.Hotspots {
+		features = append(features, geojson.NewFeature(geojson.Point(hotspot.Lat, hotspot.Lon), map[string]any{
+			"feature_type": geoFeatureHotspot,
+			"score":        hotspot.Score,
+		}))
+	}
+	return geojson.NewFeatureCollection(features...)
+}
+
+// addInterval добавляет границы интервала атрибутами {name}_lower и {name}_upper
+func addInterval(properties map[string]any, name string, interval *model.Interval) {
+	if interval == nil {
+		return
+	}
+	properties[name+"_lower"] = interval.Lower
+	properties[name+"_upper"] = interval.Upper
+}
+
+// datasetGeoJSON представляет каждый кластер датасета полигоном. Признаки за годы
+// записываются плоскими атрибутами {признак}_{год}, которые ГИС показывают как столбцы.
+func datasetGeoJSON(dataset model.Dataset) (geojson.FeatureCollection, error) {
+	features := make([]geojson.Feature, 0, len(dataset.Clusters))
+	for _, cluster := range dataset.Clusters {
+		bounds, err := model.ParseBounds(cluster.Bbox)
+		if err != nil {
+			return geojson.FeatureCollection{}, fmt.Errorf("cluster %d: %w", cluster.Index, err)
+		}
+
+		properties := map[string]any{
+			"feature_type": geoFeatureCluster,
+			"index":        cluster.Index,
+			"bbox":         cluster.Bbox,
+		}
+		years := make([]string, 0, len(cluster.Data))
+		for _, year := range cluster.Data {
+			years = append(years, strconv.Itoa(year.Year))
+			for _, features := range year.Data {
+				metrics, err := featureMetrics(features)
+				if err != nil {
+					return geojson.FeatureCollection{}, err
+				}
+				for name, value := range metrics {
+					properties[fmt.Sprintf("%s_%d", name, year.Year)] = value
+				}
+			}
+		}
+		properties["years"] = strings.Join(years, ",")
+
+		features = append(features, geojson.NewFeature(geojson.BoundsPolygon(bounds), properties))
+	}
+	return geojson.NewFeatureCollection(features...), nil
+}
+
+// featureMetrics возвращает признаки под теми же именами, что и в датасете
+func featureMetrics(features model.FeatureVector) (map[string]any, error) {
+	data, err := json.Marshal(features)
+	if err != nil {
+		return nil, err
+	}
+	var metrics map[string]any
+	if err := json.Unmarshal(data, &metrics); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
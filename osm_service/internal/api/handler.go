@@ -2,48 +2,37 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
 	"log"
-	"math"
 	"net/http"
-	"os"
 	"osm_service/internal/core"
 	"osm_service/internal/domain/model"
-	"path/filepath"
-	"sort"
+	"osm_service/internal/jobs"
 	"strconv"
-	"strings"
 	"time"
 )
 
 // Dataset types
-type ClusterData struct {
-	Index int        `json:"index"`
-	Bbox  string     `json:"bbox"`
-	Data  []YearData `json:"data"`
-}
+type ClusterData = model.DatasetCluster
 
-type YearData struct {
-	Year int           `json:"year"`
-	Data []FeatureData `json:"data"`
-}
+type YearData = model.DatasetYear
 
-type FeatureData struct {
-	AvgArea          float64 `json:"avg_area"`
-	AvgDistToPrimary float64 `json:"avg_dist_to_primary"`
-	AvgDistToSubway  float64 `json:"avg_dist_to_subway"`
-	ClosureRate      float64 `json:"closure_rate"`
-	NewObjectRate    float64 `json:"new_object_rate"`
-	ObjectDensity    float64 `json:"object_density"`
-	TotalObjects     int     `json:"total_objects"`
-}
+// FeatureData is a yearly feature row of a cluster, shared with the prediction path
+type FeatureData = model.FeatureVector
 
 type Handler struct {
-	service *core.PredictionService
+	service  *core.PredictionService
+	jobs     *jobs.Manager
+	tiles    *tileCache
+	datasets *datasetCache
 }
 
-func NewHandler(service *core.PredictionService) *Handler {
-	return &Handler{service: service}
+func NewHandler(service *core.PredictionService, jobManager *jobs.Manager) *Handler {
+	return &Handler{
+		service:  service,
+		jobs:     jobManager,
+		tiles:    newTileCache(tileCacheSize, tileCacheTTL),
+		datasets: newDatasetCache(datasetCacheSize),
+	}
 }
 
 type PredictionRequest struct {
@@ -74,11 +63,13 @@ type TrainingRequest struct {
 	ClusterSize float64 `json:"cluster_size"` // Size of cluster in square kilometers
 	StartDate   string  `json:"start_date"`   // Start date in format "2006-01-02"
 	EndDate     string  `json:"end_date"`     // End date in format "2006-01-02"
+
+	SamplingMonths int `json:"sampling_months"` // Months between snapshots: 1, 2, 3, 4, 6 or 12 (yearly, default)
 }
 
 type TrainingResponse struct {
 	Message string `json:"message"`
-	Path    string `json:"path"`
+	JobID   string `json:"job_id"`
 }
 
 type PredictRequest struct {
@@ -86,6 +77,8 @@ type PredictRequest struct {
 	ShopType       string `json:"shop_type"`
 	PredictionYear string `json:"prediction_year"`
 	TrainPeriod    string `json:"train_period"`
+	Explain        bool   `json:"explain"`  // Include feature values and contributions
+	Ensemble       bool   `json:"ensemble"` // Combine all registered models covering the area
 }
 
 type ModelInfo struct {
@@ -98,42 +91,80 @@ type ModelInfo struct {
 	} `json:"metrics"`
 }
 
+// Validate проверяет поля запроса предсказания и возвращает все найденные ошибки
+func (req PredictRequest) Validate() error {
+	var validation core.ValidationError
+	if req.BBox == "" {
+		validation.Add("bbox", "is required")
+	} else if _, err := model.ParseBounds(req.BBox); err != nil {
+		validation.Add("bbox", "%v", err)
+	}
+	if req.ShopType == "" {
+		validation.Add("shop_type", "is required")
+	}
+	if req.PredictionYear == "" {
+		validation.Add("prediction_year", "is required")
+	} else if _, err := strconv.Atoi(req.PredictionYear); err != nil {
+		validation.Add("prediction_year", "must be a year, got %q", req.PredictionYear)
+	}
+	// Ансамбль сам выбирает модели и периоды обучения и не раскладывает вклады признаков
+	if req.Ensemble {
+		if req.Explain {
+			validation.Add("explain", "is not supported with ensemble")
+		}
+		if req.TrainPeriod != "" {
+			validation.Add("train_period", "is not supported with ensemble, periods of the registered models are used")
+		}
+	}
+	return validation.Err()
+}
+
+// fallbackHeader отмечает ответ, построенный прогнозом ряда, потому что модель недоступна
+const fallbackHeader = "X-Prediction-Fallback"
+
 func (h *Handler) Predict(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, r)
 		return
 	}
 
 	var req PredictRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeInvalidBody(w, r, err)
 		return
 	}
+	// Режимы можно включить и параметрами строки запроса
+	req.Ensemble = req.Ensemble || r.URL.Query().Get("ensemble") == "true"
+	req.Explain = req.Explain || r.URL.Query().Get("explain") == "true"
 
-	if req.BBox == "" {
-		http.Error(w, "BBox is required", http.StatusBadRequest)
+	if err := req.Validate(); err != nil {
+		writeServiceError(w, r, err, "validating prediction request")
 		return
 	}
 
-	if req.ShopType == "" {
-		http.Error(w, "Shop type is required", http.StatusBadRequest)
-		return
+	// Получаем предсказание. Без train_period модель выбирается по реестру
+	var prediction *model.Prediction
+	var err error
+	switch {
+	case req.Ensemble:
+		prediction, err = h.service.EnsemblePrediction(r.Context(), req.BBox, req.ShopType, req.PredictionYear)
+	case req.Explain:
+		prediction, err = h.service.ExplainPrediction(r.Context(), req.BBox, req.ShopType, req.PredictionYear, req.TrainPeriod)
+	default:
+		prediction, err = h.service.GetPrediction(r.Context(), req.BBox, req.ShopType, req.PredictionYear, req.TrainPeriod)
 	}
-
-	if req.PredictionYear == "" {
-		http.Error(w, "Prediction year is required", http.StatusBadRequest)
+	if err != nil {
+		writeServiceError(w, r, err, "getting prediction")
 		return
 	}
-
-	if req.TrainPeriod == "" {
-		http.Error(w, "Train period is required", http.StatusBadRequest)
-		return
+	// Ответ без модели помечается и заголовком, чтобы клиент мог отличить его, не разбирая тело
+	if prediction.Fallback {
+		w.Header().Set(fallbackHeader, "forecast")
 	}
 
-	// Получаем предсказание
-	prediction, err := h.service.GetPrediction(req.BBox, req.ShopType, req.PredictionYear, req.TrainPeriod)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Error getting prediction: %v", err), http.StatusInternalServerError)
+	if wantsGeoJSON(r) {
+		bounds, _ := model.ParseBounds(req.BBox)
+		writeGeoJSON(w, predictionGeoJSON(req, bounds, prediction))
 		return
 	}
 
@@ -141,264 +172,107 @@ func (h *Handler) Predict(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(prediction)
 }
 
-func (h *Handler) Training(w http.ResponseWriter, r *http.Request) {
-	var req TrainingRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
-		return
+// Validate проверяет поля запроса построения датасета и возвращает параметры построения.
+// Ошибки всех полей собираются в одну core.ValidationError.
+func (req TrainingRequest) Validate() (model.DatasetRequest, error) {
+	var validation core.ValidationError
+
+	bounds, boundsErr := model.ParseBounds(req.BBox)
+	if req.BBox == "" {
+		validation.Add("bbox", "is required")
+	} else if boundsErr != nil {
+		validation.Add("bbox", "%v", boundsErr)
+	}
+	if req.ShopType == "" {
+		validation.Add("shop_type", "is required")
 	}
 
 	// Validate dates
-	startDate, err := time.Parse("2006-01-02", req.StartDate)
-	if err != nil {
-		http.Error(w, "Invalid start_date format. Use YYYY-MM-DD", http.StatusBadRequest)
-		return
+	startDate, startErr := time.Parse("2006-01-02", req.StartDate)
+	if startErr != nil {
+		validation.Add("start_date", "invalid format, use YYYY-MM-DD")
 	}
-
-	endDate, err := time.Parse("2006-01-02", req.EndDate)
-	if err != nil {
-		http.Error(w, "Invalid end_date format. Use YYYY-MM-DD", http.StatusBadRequest)
-		return
+	endDate, endErr := time.Parse("2006-01-02", req.EndDate)
+	if endErr != nil {
+		validation.Add("end_date", "invalid format, use YYYY-MM-DD")
 	}
-
-	if endDate.Before(startDate) {
-		http.Error(w, "end_date must be after start_date", http.StatusBadRequest)
-		return
+	if startErr == nil && endErr == nil && endDate.Before(startDate) {
+		validation.Add("end_date", "must be after start_date")
 	}
 
-	// Validate cluster size
-	if req.ClusterSize <= 0 {
-		http.Error(w, "cluster_size must be positive", http.StatusBadRequest)
-		return
+	// Validate cluster size (an invalid bbox gives empty bounds and is not counted)
+	if err := core.ValidateClusterSize(bounds, req.ClusterSize, core.MaxClusters); err != nil {
+		validation.Add("cluster_size", "%v", err)
 	}
 
-	// Generate clusters
-	clusters, err := generateClusters(req.BBox, req.ClusterSize)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to generate clusters: %v", err), http.StatusBadRequest)
-		return
+	// Validate sampling interval
+	if req.SamplingMonths == 0 {
+		req.SamplingMonths = model.DefaultSamplingMonths
 	}
-
-	// Generate filename with date range and shop type
-	filename := fmt.Sprintf("dataset_%s_%s_to_%s.json",
-		req.ShopType,
-		startDate.Format("20060102"),
-		endDate.Format("20060102"))
-
-	// Create datasets directory in parent directory (same level as Dockerfile)
-	datasetsDir := filepath.Join("..", "datasets")
-	if err := os.MkdirAll(datasetsDir, 0755); err != nil {
-		log.Printf("Error creating datasets directory: %v", err)
-		http.Error(w, fmt.Sprintf("Failed to create datasets directory: %v", err), http.StatusInternalServerError)
-		return
+	// Шаг должен делить год, иначе снимки разных лет попадают на разные фазы
+	// сезонного цикла и сезонность в признаках не выделяется
+	if req.SamplingMonths < 0 || req.SamplingMonths > 12 || 12%req.SamplingMonths != 0 {
+		validation.Add("sampling_months", "must divide a year: 1, 2, 3, 4, 6 or 12, got %d", req.SamplingMonths)
 	}
 
-	// Create full filepath
-	filepath := filepath.Join(datasetsDir, filename)
-	log.Printf("Saving dataset to: %s", filepath)
+	return model.DatasetRequest{
+		BBox:           req.BBox,
+		ShopType:       req.ShopType,
+		ClusterSize:    req.ClusterSize,
+		StartDate:      startDate,
+		EndDate:        endDate,
+		SamplingMonths: req.SamplingMonths,
+	}, validation.Err()
+}
 
-	// Create file
-	file, err := os.Create(filepath)
-	if err != nil {
-		log.Printf("Error creating file: %v", err)
-		http.Error(w, fmt.Sprintf("Failed to create dataset file: %v", err), http.StatusInternalServerError)
+func (h *Handler) Training(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
 		return
 	}
-	defer file.Close()
-
-	// Create JSON encoder
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
 
-	dataset := struct {
-		Clusters []ClusterData `json:"clusters"`
-	}{
-		Clusters: make([]ClusterData, len(clusters)),
+	var req TrainingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r, err)
+		return
 	}
 
-	// Process each cluster
-	for i, cluster := range clusters {
-		log.Printf("Processing cluster %d/%d", i+1, len(clusters))
-
-		dataset.Clusters[i].Index = i
-		dataset.Clusters[i].Bbox = fmt.Sprintf("%f,%f,%f,%f",
-			cluster.MinLat, cluster.MinLon, cluster.MaxLat, cluster.MaxLon)
-
-		// Get historical data for the cluster
-		historical, err := h.service.GetHistoricalDataForPeriod(r.Context(), cluster, startDate, endDate, req.ShopType)
-		if err != nil {
-			log.Printf("Warning: Failed to get historical data for cluster: %v", err)
-			continue
-		}
-
-		// Get current data for the cluster
-		current, err := h.service.GetCurrentData(r.Context(), cluster, req.ShopType)
-		if err != nil {
-			log.Printf("Warning: Failed to get current data for cluster: %v", err)
-			continue
-		}
-
-		// Calculate features with cluster bounds
-		features := h.service.CalculateFeaturesForPeriod(r.Context(), current, historical, startDate, endDate, cluster)
-
-		// Create yearly data
-		yearlyData := make([]YearData, 0)
-		currentYear := startDate.Year()
-		endYear := endDate.Year()
-
-		// Create map for yearly historical data
-		yearlyHistoricalData := make(map[string]model.HistoricalData)
-		for _, data := range historical {
-			year := data.Period[:4]
-			yearlyHistoricalData[year] = data
-		}
-
-		// Sort years for correct trend calculation
-		var years []string
-		for year := currentYear; year <= endYear; year++ {
-			years = append(years, fmt.Sprintf("%d", year))
-		}
-		sort.Strings(years)
-
-		for _, yearStr := range years {
-			yearData, exists := yearlyHistoricalData[yearStr]
-
-			// If no data for specific year, use data from last available year
-			if !exists {
-				var lastYear string
-				for y := range yearlyHistoricalData {
-					if y <= yearStr && (lastYear == "" || y > lastYear) {
-						lastYear = y
-					}
-				}
-				if lastYear != "" {
-					yearData = yearlyHistoricalData[lastYear]
-				}
-			}
-
-			// Calculate metrics for current year
-			totalObjects := yearData.TotalObjects
-			newObjects := yearData.NewObjects
-			closedObjects := yearData.ClosedObjects
-
-			// Calculate growth and closure rates
-			var newObjectRate, closureRate float64
-			if totalObjects > 0 {
-				newObjectRate = float64(newObjects) / float64(totalObjects)
-				closureRate = float64(closedObjects) / float64(totalObjects)
-			}
-
-			// Calculate object density
-			objectDensity := float64(totalObjects) / features.Spatial.AvgArea
-
-			// Create feature data
-			featureData := FeatureData{
-				AvgArea:          features.Spatial.AvgArea,
-				AvgDistToPrimary: features.Spatial.AvgDistToPrimary,
-				AvgDistToSubway:  features.Spatial.AvgDistToSubway,
-				ClosureRate:      closureRate,
-				NewObjectRate:    newObjectRate,
-				ObjectDensity:    objectDensity,
-				TotalObjects:     totalObjects,
-			}
-
-			// Add year data
-			yearInt, _ := strconv.Atoi(yearStr)
-			yearlyData = append(yearlyData, YearData{
-				Year: yearInt,
-				Data: []FeatureData{featureData},
-			})
-		}
-
-		dataset.Clusters[i].Data = yearlyData
+	datasetRequest, err := req.Validate()
+	if err != nil {
+		writeServiceError(w, r, err, "validating training request")
+		return
 	}
 
-	// Write dataset to file
-	if err := encoder.Encode(dataset); err != nil {
-		log.Printf("Error writing dataset: %v", err)
-		http.Error(w, fmt.Sprintf("Failed to write dataset: %v", err), http.StatusInternalServerError)
+	// Датасет строится в фоне, клиент следит за задачей через /api/jobs/{id}
+	status, err := h.jobs.Submit(jobKindDataset, h.datasetTask(datasetRequest))
+	if err != nil {
+		writeJobError(w, r, err)
 		return
 	}
 
-	log.Printf("Dataset successfully saved to: %s", filepath)
+	log.Printf("Dataset job %s queued for %s", status.ID, core.DatasetFilename(datasetRequest))
 
-	// Return success response
 	response := TrainingResponse{
-		Message: "Dataset created successfully",
-		Path:    filepath,
+		Message: "Dataset job queued",
+		JobID:   status.ID,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
 	json.NewEncoder(w).Encode(response)
 }
 
-// generateClusters splits the bounding box into smaller clusters
-func generateClusters(bbox string, clusterSize float64) ([]model.Bounds, error) {
-	// Parse bbox string: "lat1,lon1,lat2,lon2"
-	parts := strings.Split(bbox, ",")
-	if len(parts) != 4 {
-		return nil, fmt.Errorf("invalid bbox format")
-	}
-
-	minLat, err := strconv.ParseFloat(parts[0], 64)
-	if err != nil {
-		return nil, fmt.Errorf("invalid min_lat: %w", err)
-	}
-	minLon, err := strconv.ParseFloat(parts[1], 64)
-	if err != nil {
-		return nil, fmt.Errorf("invalid min_lon: %w", err)
-	}
-	maxLat, err := strconv.ParseFloat(parts[2], 64)
-	if err != nil {
-		return nil, fmt.Errorf("invalid max_lat: %w", err)
-	}
-	maxLon, err := strconv.ParseFloat(parts[3], 64)
-	if err != nil {
-		return nil, fmt.Errorf("invalid max_lon: %w", err)
-	}
-
-	// Calculate number of clusters in each direction
-	latDiff := maxLat - minLat
-	lonDiff := maxLon - minLon
-
-	// Approximate conversion from degrees to kilometers (at equator)
-	latKm := latDiff * 111.32
-	lonKm := lonDiff * 111.32 * math.Cos(minLat*math.Pi/180)
-
-	clustersLat := int(math.Ceil(latKm / clusterSize))
-	clustersLon := int(math.Ceil(lonKm / clusterSize))
-
-	// Generate clusters
-	var clusters []model.Bounds
-	latStep := latDiff / float64(clustersLat)
-	lonStep := lonDiff / float64(clustersLon)
-
-	for i := 0; i < clustersLat; i++ {
-		for j := 0; j < clustersLon; j++ {
-			cluster := model.Bounds{
-				MinLat: minLat + float64(i)*latStep,
-				MinLon: minLon + float64(j)*lonStep,
-				MaxLat: minLat + float64(i+1)*latStep,
-				MaxLon: minLon + float64(j+1)*lonStep,
-			}
-			clusters = append(clusters, cluster)
-		}
-	}
-
-	return clusters, nil
-}
-
 // GetModels возвращает список доступных моделей
 func (h *Handler) GetModels(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, r)
 		return
 	}
 
 	// Получаем список моделей из ML сервиса
-	models, err := h.service.GetAvailableModels()
+	models, err := h.service.GetAvailableModels(r.Context())
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error getting models: %v", err), http.StatusInternalServerError)
+		writeServiceError(w, r, err, "getting models")
 		return
 	}
 
@@ -0,0 +1,457 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"osm_service/internal/core"
+	"osm_service/internal/domain/model"
+	"osm_service/internal/domain/repository"
+	"osm_service/internal/jobs"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Подменные зависимости сервиса: Overpass отвечает фиксированным набором объектов,
+// ML сервис, реестр моделей и журнал предсказаний хранят данные в памяти
+
+const testBBox = "55.780000,37.580000,55.800000,37.600000"
+
+// fakeOverpass отвечает на запросы объектов, станций метро и дорог одним и тем же снимком
+func fakeOverpass(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		query := string(body)
+		if r.URL.RawQuery != "" {
+			query += r.URL.RawQuery
+		}
+
+		var elements []map[string]any
+		switch {
+		case strings.Contains(query, "railway"):
+			elements = []map[string]any{
+				{"type": "node", "id": 500, "lat": 55.79, "lon": 37.59, "tags": map[string]string{"railway": "station", "station": "subway", "name": "Тестовая"}},
+			}
+		case strings.Contains(query, "highway"):
+			elements = []map[string]any{
+				{"type": "way", "id": 600, "nodes": []int64{601, 602}, "tags": map[string]string{"highway": "primary"}},
+				{"type": "node", "id": 601, "lat": 55.781, "lon": 37.581},
+				{"type": "node", "id": 602, "lat": 55.799, "lon": 37.599},
+			}
+		default:
+			for i := range 5 {
+				elements = append(elements, map[string]any{
+					"type": "node", "id": 100 + i, "lat": 55.785 + 0.002*float64(i), "lon": 37.585 + 0.002*float64(i),
+					"tags": map[string]string{"shop": "bakery", "name": fmt.Sprintf("Пекарня %d", i)},
+				})
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"elements": elements})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+type fakeMLClient struct{}
+
+func (fakeMLClient) GetAvailableModels(ctx context.Context) ([]model.ModelInfo, error) {
+	return []model.ModelInfo{{Name: "model_bakery_2019-2023", ShopType: "bakery", TrainYear: "2019-2023", BBox: testBBox}}, nil
+}
+
+func (fakeMLClient) GetPrediction(ctx context.Context, query model.PredictionQuery) (*model.Prediction, error) {
+	return &model.Prediction{
+		ActivityLevel: 0.6,
+		Trend:         0.1,
+		PredictedNew:  2,
+		TotalObjects:  7,
+		ModelUsed:     "model_bakery_2019-2023",
+	}, nil
+}
+
+type fakeModelRegistry struct {
+	mu     sync.Mutex
+	models map[string]model.RegisteredModel
+}
+
+func newFakeModelRegistry() *fakeModelRegistry {
+	return &fakeModelRegistry{models: make(map[string]model.RegisteredModel)}
+}
+
+func (r *fakeModelRegistry) RegisterModel(ctx context.Context, m model.RegisteredModel) (model.RegisteredModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.models[m.Name]; ok {
+		return model.RegisteredModel{}, fmt.Errorf("%w: %s", model.ErrModelExists, m.Name)
+	}
+	m.ID = int64(len(r.models) + 1)
+	m.CreatedAt = time.Now()
+	r.models[m.Name] = m
+	return m, nil
+}
+
+func (r *fakeModelRegistry) ListModels(ctx context.Context, filter model.ModelFilter) ([]model.RegisteredModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	models := []model.RegisteredModel{}
+	for _, m := range r.models {
+		if (filter.Category == "" || m.Category == filter.Category) && (filter.Status == "" || m.Status == filter.Status) {
+			models = append(models, m)
+		}
+	}
+	return models, nil
+}
+
+func (r *fakeModelRegistry) GetModel(ctx context.Context, name string) (model.RegisteredModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.models[name]
+	if !ok {
+		return model.RegisteredModel{}, fmt.Errorf("%w: %s", model.ErrModelNotFound, name)
+	}
+	return m, nil
+}
+
+func (r *fakeModelRegistry) UpdateStatus(ctx context.Context, name string, status string) (model.RegisteredModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.models[name]
+	if !ok {
+		return model.RegisteredModel{}, fmt.Errorf("%w: %s", model.ErrModelNotFound, name)
+	}
+	m.Status = status
+	r.models[name] = m
+	return m, nil
+}
+
+func (r *fakeModelRegistry) FindBestModel(ctx context.Context, category string, bbox string) (model.RegisteredModel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.models {
+		if m.Category == category && m.Status == model.ModelStatusActive {
+			return m, nil
+		}
+	}
+	return model.RegisteredModel{}, fmt.Errorf("%w for category %s in bbox %s", model.ErrModelNotFound, category, bbox)
+}
+
+type fakePredictionLog struct {
+	mu      sync.Mutex
+	records []model.PredictionRecord
+	truths  []model.GroundTruth
+}
+
+func (l *fakePredictionLog) SavePrediction(ctx context.Context, record model.PredictionRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, record)
+	return nil
+}
+
+func (l *fakePredictionLog) SaveGroundTruth(ctx context.Context, truth model.GroundTruth) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.truths = append(l.truths, truth)
+	return nil
+}
+
+func (l *fakePredictionLog) ModelAccuracy(ctx context.Context) ([]model.ModelAccuracy, error) {
+	return []model.ModelAccuracy{}, nil
+}
+
+func (l *fakePredictionLog) ModelDivergence(ctx context.Context) ([]model.ModelDivergence, error) {
+	return []model.ModelDivergence{}, nil
+}
+
+// newTestServer поднимает API с подменными зависимостями и активной моделью bakery в реестре
+func newTestServer(t *testing.T) (*httptest.Server, []Route) {
+	t.Helper()
+	overpassRepo := repository.NewOverpassRepository(fakeOverpass(t).URL, 10*time.Second, 1)
+	service := core.NewPredictionService(*overpassRepo, repository.PostGISRepository{}, fakeMLClient{}, nil, false)
+
+	registry := newFakeModelRegistry()
+	registry.models["model_bakery_2019-2023"] = model.RegisteredModel{
+		ID:             1,
+		Name:           "model_bakery_2019-2023",
+		Category:       "bakery",
+		TrainStart:     time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		TrainEnd:       time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		Coverage:       testBBox,
+		SamplingMonths: 12,
+		Status:         model.ModelStatusActive,
+	}
+	service.SetModelRegistry(registry)
+	service.SetPredictionLog(&fakePredictionLog{})
+
+	jobManager := jobs.NewManager(1)
+	routes := NewHandler(service, jobManager).Routes()
+	mux := http.NewServeMux()
+	Register(mux, APIVersionPrefix, routes, nil)
+	server := httptest.NewServer(WithErrorResponses(mux))
+	t.Cleanup(server.Close)
+	return server, routes
+}
+
+// findRoute возвращает маршрут таблицы, которым обслуживается запрос
+func findRoute(t *testing.T, routes []Route, method, path string) Route {
+	t.Helper()
+	mux := http.NewServeMux()
+	for _, route := range routes {
+		mux.HandleFunc(route.Method+" "+APIVersionPrefix+route.Path, func(http.ResponseWriter, *http.Request) {})
+	}
+	req := httptest.NewRequest(method, path, nil)
+	_, pattern := mux.Handler(req)
+	for _, route := range routes {
+		if route.Method+" "+APIVersionPrefix+route.Path == pattern {
+			return route
+		}
+	}
+	t.Fatalf("no route for %s %s", method, path)
+	return Route{}
+}
+
+// TestHandlerRoutes отправляет на маршруты примеры запросов и проверяет код ответа
+// и его соответствие типу из таблицы маршрутов. Случаи выполняются по порядку:
+// изменение статуса модели идет после запросов, которым нужна активная модель.
+func TestHandlerRoutes(t *testing.T) {
+	server, routes := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		status int
+		fields []string // поля ошибки проверки для ответа 400
+	}{
+		{"health", http.MethodGet, "/health", nil, http.StatusOK, nil},
+		{"openapi", http.MethodGet, "/openapi.json", nil, http.StatusOK, nil},
+
+		{"predict", http.MethodPost, "/predict", map[string]any{
+			"bbox": testBBox, "shop_type": "bakery", "prediction_year": "2025",
+		}, http.StatusOK, nil},
+		{"predict invalid", http.MethodPost, "/predict", map[string]any{
+			"bbox": "55.78,37.58", "prediction_year": "next",
+		}, http.StatusBadRequest, []string{"bbox", "shop_type", "prediction_year"}},
+		{"predict malformed body", http.MethodPost, "/predict", "{", http.StatusBadRequest, nil},
+		{"batch", http.MethodPost, "/predict/batch", map[string]any{
+			"areas":     []map[string]any{{"id": "a", "bbox": testBBox}, {"id": "b", "bbox": testBBox}},
+			"shop_type": "bakery", "prediction_year": "2025",
+		}, http.StatusOK, nil},
+		{"batch invalid", http.MethodPost, "/predict/batch", map[string]any{
+			"shop_type": "bakery",
+		}, http.StatusBadRequest, nil},
+		{"compare", http.MethodPost, "/compare", map[string]any{
+			"areas":           []map[string]any{{"name": "a", "bbox": testBBox}, {"name": "b", "bbox": testBBox}},
+			"shop_type":       "bakery",
+			"prediction_year": "2025",
+		}, http.StatusOK, nil},
+		{"compare invalid", http.MethodPost, "/compare", map[string]any{
+			"areas": []map[string]any{{"bbox": testBBox}},
+		}, http.StatusBadRequest, nil},
+		{"training invalid", http.MethodPost, "/training", map[string]any{
+			"bbox": testBBox, "shop_type": "bakery", "cluster_size": 1, "start_date": "2023-01-01", "end_date": "2019-01-01",
+		}, http.StatusBadRequest, []string{"end_date"}},
+
+		{"dataset invalid name", http.MethodGet, "/datasets/..%2Fsecret", nil, http.StatusBadRequest, []string{"name"}},
+		{"dataset not found", http.MethodGet, "/datasets/dataset_cafe_20190101_to_20230101.json", nil, http.StatusNotFound, nil},
+		{"tile unknown layer", http.MethodGet, "/tiles/roads/14/9903/5121.mvt", nil, http.StatusBadRequest, []string{"layer"}},
+		{"tile", http.MethodGet, "/tiles/pois/13/4951/2559.mvt?shop_type=bakery", nil, http.StatusOK, nil},
+		{"tile invalid address", http.MethodGet, "/tiles/pois/1/5/5.mvt", nil, http.StatusBadRequest, []string{"tile"}},
+
+		{"job not found", http.MethodGet, "/jobs/unknown", nil, http.StatusNotFound, nil},
+		{"cancel job not found", http.MethodDelete, "/jobs/unknown", nil, http.StatusNotFound, nil},
+		{"resume without checkpoints", http.MethodPost, "/jobs/unknown/resume", nil, http.StatusNotImplemented, nil},
+		{"job events not found", http.MethodGet, "/jobs/unknown/events", nil, http.StatusNotFound, nil},
+
+		{"list models", http.MethodGet, "/models?category=bakery", nil, http.StatusOK, nil},
+		{"list ml models", http.MethodGet, "/models?source=ml", nil, http.StatusOK, nil},
+		{"lookup model", http.MethodGet, "/models/lookup?category=bakery&bbox=" + testBBox, nil, http.StatusOK, nil},
+		{"register model", http.MethodPost, "/models", map[string]any{
+			"name": "model_bakery_2020-2024", "category": "bakery", "train_start": "2020-01-01", "train_end": "2024-01-01",
+			"coverage": testBBox, "metrics": map[string]float64{"mse": 0.1, "rmse": 0.3, "r2": 0.8},
+		}, http.StatusCreated, nil},
+		{"register duplicate model", http.MethodPost, "/models", map[string]any{
+			"name": "model_bakery_2019-2023", "category": "bakery", "train_start": "2019-01-01", "train_end": "2023-01-01",
+			"coverage": testBBox,
+		}, http.StatusConflict, nil},
+		{"register invalid model", http.MethodPost, "/models", map[string]any{
+			"train_start": "2019", "coverage": testBBox,
+		}, http.StatusBadRequest, []string{"train_start", "train_end", "name", "category"}},
+		{"update model status", http.MethodPatch, "/models/model_bakery_2019-2023", map[string]any{
+			"status": "retired",
+		}, http.StatusOK, nil},
+		{"update unknown model", http.MethodPatch, "/models/unknown", map[string]any{
+			"status": "active",
+		}, http.StatusNotFound, nil},
+		{"update model invalid status", http.MethodPatch, "/models/model_bakery_2019-2023", map[string]any{
+			"status": "deleted",
+		}, http.StatusBadRequest, []string{"status"}},
+		{"lookup model invalid", http.MethodGet, "/models/lookup?bbox=bad", nil, http.StatusBadRequest, []string{"category", "bbox"}},
+
+		{"evaluation compare", http.MethodGet, "/evaluation/compare", nil, http.StatusOK, nil},
+		{"ground truth", http.MethodPost, "/evaluation/ground-truth", map[string]any{
+			"bbox": testBBox, "shop_type": "bakery", "year": "2024", "actual_new": 1, "actual_closed": 0,
+		}, http.StatusNoContent, nil},
+		{"ground truth invalid", http.MethodPost, "/evaluation/ground-truth", map[string]any{
+			"bbox": "bad",
+		}, http.StatusBadRequest, []string{"bbox", "shop_type", "year"}},
+
+		{"change points", http.MethodPost, "/analysis/changepoints", map[string]any{
+			"bbox": testBBox, "shop_type": "bakery", "start_date": "2019-01-01", "end_date": "2023-01-01",
+		}, http.StatusOK, nil},
+		{"change points invalid", http.MethodPost, "/analysis/changepoints", map[string]any{
+			"start_date": "2019",
+		}, http.StatusBadRequest, []string{"bbox", "shop_type", "start_date", "end_date"}},
+		{"impact", http.MethodPost, "/analysis/impact", map[string]any{
+			"bbox": testBBox, "shop_type": "bakery", "start_date": "2019-01-01", "end_date": "2023-01-01",
+		}, http.StatusOK, nil},
+		{"impact invalid", http.MethodPost, "/analysis/impact", map[string]any{
+			"bbox": testBBox, "shop_type": "bakery", "start_date": "2023-01-01", "end_date": "2019-01-01", "control_outer": -1,
+		}, http.StatusBadRequest, []string{"end_date", "control_outer"}},
+		{"backtest invalid", http.MethodPost, "/backtest", map[string]any{
+			"bbox": testBBox, "cluster_size": 2,
+		}, http.StatusBadRequest, []string{"shop_types", "cutoff_date"}},
+	}
+
+	// Параметр source=ml меняет тип ответа, который не описан в таблице маршрутов
+	responses := map[string]any{
+		"list ml models": []model.ModelInfo{},
+	}
+
+	covered := make(map[string]bool)
+	for _, tt := range tests {
+		route := findRoute(t, routes, tt.method, APIVersionPrefix+tt.path)
+		covered[route.Method+" "+route.Path] = true
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			switch b := tt.body.(type) {
+			case nil:
+			case string:
+				body = strings.NewReader(b)
+			default:
+				data, err := json.Marshal(b)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = bytes.NewReader(data)
+			}
+
+			req, err := http.NewRequest(tt.method, server.URL+APIVersionPrefix+tt.path, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			data, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.status, data)
+			}
+
+			route := findRoute(t, routes, tt.method, APIVersionPrefix+tt.path)
+			if response, ok := responses[tt.name]; ok {
+				route.Response = response
+			}
+			if resp.StatusCode >= 400 {
+				checkErrorResponse(t, data, tt.fields)
+				return
+			}
+			checkResponseSchema(t, route, resp, data)
+		})
+	}
+
+	for _, route := range routes {
+		if !covered[route.Method+" "+route.Path] {
+			t.Errorf("no test case for %s %s", route.Method, route.Path)
+		}
+	}
+}
+
+// checkErrorResponse проверяет, что ошибка отдана в формате ErrorResponse
+// и, для ошибок проверки, содержит все ожидаемые поля
+func checkErrorResponse(t *testing.T, data []byte, fields []string) {
+	t.Helper()
+	var response ErrorResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		t.Fatalf("decode ErrorResponse: %v: %s", err, data)
+	}
+	if response.Code == "" || response.RequestID == "" {
+		t.Errorf("incomplete error response %s", data)
+	}
+	if fields == nil {
+		return
+	}
+
+	var details struct {
+		Details []core.FieldError `json:"details"`
+	}
+	if err := json.Unmarshal(data, &details); err != nil {
+		t.Fatalf("decode validation details: %v: %s", err, data)
+	}
+	got := make(map[string]bool)
+	for _, field := range details.Details {
+		got[field.Field] = true
+	}
+	for _, field := range fields {
+		if !got[field] {
+			t.Errorf("expected invalid field %s in %s", field, data)
+		}
+	}
+	if len(got) != len(fields) {
+		t.Errorf("expected invalid fields %v, got %s", fields, data)
+	}
+}
+
+// checkResponseSchema разбирает ответ в тип Response маршрута без неизвестных полей,
+// чтобы ответ совпадал со схемой OpenAPI документа
+func checkResponseSchema(t *testing.T, route Route, resp *http.Response, data []byte) {
+	t.Helper()
+	if route.Response == nil {
+		if len(data) != 0 {
+			t.Errorf("expected empty body, got %s", data)
+		}
+		return
+	}
+
+	contentType := route.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, contentType) {
+		t.Errorf("Content-Type = %q, want %q", got, contentType)
+	}
+	var lines [][]byte
+	switch contentType {
+	case "application/json":
+		lines = [][]byte{data}
+	case ndjsonMediaType:
+		lines = bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	default:
+		if len(data) == 0 {
+			t.Errorf("expected %s body", contentType)
+		}
+		return
+	}
+
+	for _, line := range lines {
+		value := reflect.New(reflect.TypeOf(route.Response))
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(value.Interface()); err != nil {
+			t.Errorf("response does not match %T: %v: %s", route.Response, err, line)
+		}
+	}
+}
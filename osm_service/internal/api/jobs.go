@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"osm_service/internal/domain/model"
+	"osm_service/internal/jobs"
+	"time"
+)
+
+// jobKindDataset — тип задачи построения датасета
+const jobKindDataset = "dataset"
+
+// sseHeartbeat — интервал комментариев в потоке событий задачи
+const sseHeartbeat = 15 * time.Second
+
+// Jobs возвращает состояние задачи (GET) или отменяет ее (DELETE): /jobs/{id}
+func (h *Handler) Jobs(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var status jobs.Status
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		status, err = h.jobs.Get(id)
+	case http.MethodDelete:
+		status, err = h.jobs.Cancel(id)
+		// Задача из очереди отменяется сразу и не запустится, поэтому ее чекпоинт
+		// удаляется здесь; выполняющаяся задача удаляет его сама после остановки
+		if err == nil && status.Kind == jobKindDataset && status.State == jobs.StateCancelled {
+			if removeErr := h.service.RemoveDatasetCheckpoint(r.Context(), id); removeErr != nil {
+				log.Printf("Warning: failed to remove checkpoint of cancelled job %s: %v", id, removeErr)
+			}
+		}
+	default:
+		writeMethodNotAllowed(w, r)
+		return
+	}
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// JobEvents передает события задачи в формате Server-Sent Events: сначала текущее
+// состояние, затем события по мере выполнения и итоговое событие done
+func (h *Handler) JobEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	id := r.PathValue("id")
+
+	status, events, unsubscribe, err := h.jobs.Subscribe(id)
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+	defer unsubscribe()
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx не должен буферизовать поток
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event jobs.Event) bool {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+			return false
+		}
+		return controller.Flush() == nil
+	}
+
+	if !send(jobs.StatusEvent(jobs.EventState, status)) {
+		return
+	}
+
+	// Комментарии не дают прокси закрыть соединение, пока событий нет
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// Задача завершена: итоговое состояние берется из нее, а не из буфера событий
+				if final, err := h.jobs.Get(id); err == nil {
+					send(jobs.StatusEvent(jobs.EventDone, final))
+				}
+				return
+			}
+			if !send(event) {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil || controller.Flush() != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// ResumeJob снова запускает построение датасета по чекпоинту задачи id:
+// готовые кластеры берутся из чекпоинта, остальные считаются заново
+func (h *Handler) ResumeJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	id := r.PathValue("id")
+
+	req, err := h.service.DatasetCheckpointRequest(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err, "loading checkpoint")
+		return
+	}
+
+	status, err := h.jobs.Resume(id, jobKindDataset, h.datasetTask(req))
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(status)
+}
+
+// writeJobError отправляет ошибку менеджера задач
+func writeJobError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, jobs.ErrJobNotFound):
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Job not found", nil)
+	case errors.Is(err, jobs.ErrJobFinished), errors.Is(err, jobs.ErrJobActive):
+		writeError(w, r, http.StatusConflict, CodeConflict, err.Error(), nil)
+	case errors.Is(err, jobs.ErrQueueFull):
+		writeError(w, r, http.StatusServiceUnavailable, CodeUnavailable, "Job queue is full, try again later", nil)
+	default:
+		writeServiceError(w, r, err, "managing job")
+	}
+}
+
+// datasetTask строит датасет в каталоге datasetsDir
+func (h *Handler) datasetTask(req model.DatasetRequest) jobs.Task {
+	return func(ctx context.Context, job *jobs.Job) (string, error) {
+		return h.service.BuildDataset(ctx, job.ID(), req, datasetsDir, job)
+	}
+}
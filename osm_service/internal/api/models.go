@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"osm_service/internal/core"
+	"osm_service/internal/domain/model"
+	"time"
+)
+
+type RegisterModelRequest struct {
+	Name                 string             `json:"name"`
+	Category             string             `json:"category"`    // Type of objects the model predicts
+	TrainStart           string             `json:"train_start"` // Start date in format "2006-01-02"
+	TrainEnd             string             `json:"train_end"`   // End date in format "2006-01-02"
+	Coverage             string             `json:"coverage"`    // Training area "minLat,minLon,maxLat,maxLon"
+	FeatureSchemaVersion string             `json:"feature_schema_version"`
+	SamplingMonths       int                `json:"sampling_months"` // Snapshot step of the training dataset, 12 by default
+	Metrics              model.ModelMetrics `json:"metrics"`
+	Status               string             `json:"status"`
+}
+
+// Validate проверяет обязательные поля и разбирает даты обучения. Статус и шаг
+// снимков, у которых есть значения по умолчанию, проверяет core при регистрации.
+func (req RegisterModelRequest) Validate() (model.RegisteredModel, error) {
+	var validation core.ValidationError
+	trainStart, startErr := time.Parse("2006-01-02", req.TrainStart)
+	if startErr != nil {
+		validation.Add("train_start", "invalid format, use YYYY-MM-DD")
+	}
+	trainEnd, endErr := time.Parse("2006-01-02", req.TrainEnd)
+	if endErr != nil {
+		validation.Add("train_end", "invalid format, use YYYY-MM-DD")
+	}
+	if req.Name == "" {
+		validation.Add("name", "is required")
+	}
+	if req.Category == "" {
+		validation.Add("category", "is required")
+	}
+	if req.Coverage == "" {
+		validation.Add("coverage", "is required")
+	} else if _, err := model.ParseBounds(req.Coverage); err != nil {
+		validation.Add("coverage", "%v", err)
+	}
+
+	return model.RegisteredModel{
+		Name:                 req.Name,
+		Category:             req.Category,
+		TrainStart:           trainStart,
+		TrainEnd:             trainEnd,
+		Coverage:             req.Coverage,
+		FeatureSchemaVersion: req.FeatureSchemaVersion,
+		SamplingMonths:       req.SamplingMonths,
+		Metrics:              req.Metrics,
+		Status:               req.Status,
+	}, validation.Err()
+}
+
+// UpdateModelRequest — изменение записи реестра
+type UpdateModelRequest struct {
+	Status string `json:"status"` // candidate, active or retired
+}
+
+// Models обрабатывает реестр моделей: GET возвращает список (с фильтрами
+// category и status, а с source=ml — модели ML сервиса), POST регистрирует модель
+func (h *Handler) Models(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if r.URL.Query().Get("source") == "ml" {
+			h.GetModels(w, r)
+			return
+		}
+		h.listModels(w, r)
+	case http.MethodPost:
+		h.registerModel(w, r)
+	default:
+		writeMethodNotAllowed(w, r)
+	}
+}
+
+func (h *Handler) listModels(w http.ResponseWriter, r *http.Request) {
+	filter := model.ModelFilter{
+		Category: r.URL.Query().Get("category"),
+		Status:   r.URL.Query().Get("status"),
+	}
+
+	models, err := h.service.ListModels(r.Context(), filter)
+	if err != nil {
+		writeServiceError(w, r, err, "listing models")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models)
+}
+
+func (h *Handler) registerModel(w http.ResponseWriter, r *http.Request) {
+	var req RegisterModelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r, err)
+		return
+	}
+
+	m, err := req.Validate()
+	if err != nil {
+		writeServiceError(w, r, err, "validating model")
+		return
+	}
+
+	registered, err := h.service.RegisterModel(r.Context(), m)
+	if err != nil {
+		writeServiceError(w, r, err, "registering model")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(registered)
+}
+
+// UpdateModel меняет статус модели реестра: /models/{name}
+func (h *Handler) UpdateModel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	var req UpdateModelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r, err)
+		return
+	}
+
+	updated, err := h.service.UpdateModelStatus(r.Context(), r.PathValue("name"), req.Status)
+	if err != nil {
+		writeServiceError(w, r, err, "updating model")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// LookupModel возвращает активную модель категории, лучше всего покрывающую bbox
+func (h *Handler) LookupModel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	category := r.URL.Query().Get("category")
+	bbox := r.URL.Query().Get("bbox")
+	var validation core.ValidationError
+	if category == "" {
+		validation.Add("category", "is required")
+	}
+	if bbox == "" {
+		validation.Add("bbox", "is required")
+	} else if _, err := model.ParseBounds(bbox); err != nil {
+		validation.Add("bbox", "%v", err)
+	}
+	if err := validation.Err(); err != nil {
+		writeServiceError(w, r, err, "validating model lookup")
+		return
+	}
+
+	registered, err := h.service.FindModel(r.Context(), category, bbox)
+	if err != nil {
+		writeServiceError(w, r, err, "finding model")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(registered)
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// openAPIVersion — версия спецификации OpenAPI, в которой строится документ
+const openAPIVersion = "3.0.3"
+
+// pathParamPattern находит параметры пути в шаблоне ServeMux: {id}, {name...}
+var pathParamPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)(?:\.\.\.)?\}`)
+
+// OpenAPI отдает OpenAPI документ текущей версии API
+func (h *Handler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(OpenAPIDocument(h.Routes(), APIVersionPrefix))
+}
+
+// OpenAPIDocument строит OpenAPI документ по таблице маршрутов: схемы тел запросов
+// и ответов выводятся из Go типов по тегам json
+func OpenAPIDocument(routes []Route, prefix string) map[string]any {
+	builder := &schemaBuilder{
+		schemas: make(map[string]any),
+		types:   make(map[string]reflect.Type),
+		names:   make(map[reflect.Type]string),
+	}
+
+	paths := make(map[string]map[string]any)
+	for _, route := range routes {
+		routePath := pathParamPattern.ReplaceAllString(prefix+route.Path, "{$1}")
+		if paths[routePath] == nil {
+			paths[routePath] = make(map[string]any)
+		}
+		paths[routePath][strings.ToLower(route.Method)] = builder.operation(route)
+	}
+
+	return map[string]any{
+		"openapi": openAPIVersion,
+		"info": map[string]any{
+			"title":   "OSM Service API",
+			"version": strings.TrimPrefix(prefix, "/api/"),
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": builder.schemas,
+		},
+	}
+}
+
+// schemaBuilder собирает схемы именованных типов в components/schemas
+type schemaBuilder struct {
+	schemas map[string]any
+	types   map[string]reflect.Type
+	names   map[reflect.Type]string
+}
+
+func (b *schemaBuilder) operation(route Route) map[string]any {
+	op := map[string]any{
+		"summary":     route.Summary,
+		"operationId": operationID(route),
+	}
+
+	var parameters []any
+	for _, match := range pathParamPattern.FindAllStringSubmatch(route.Path, -1) {
+		parameters = append(parameters, map[string]any{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]any{"type": "string"},
+		})
+	}
+	for _, param := range route.Query {
+		paramType := param.Type
+		if paramType == "" {
+			paramType = "string"
+		}
+		parameter := map[string]any{
+			"name":     param.Name,
+			"in":       "query",
+			"required": param.Required,
+			"schema":   map[string]any{"type": paramType},
+		}
+		if param.Description != "" {
+			parameter["description"] = param.Description
+		}
+		parameters = append(parameters, parameter)
+	}
+	if len(parameters) > 0 {
+		op["parameters"] = parameters
+	}
+
+	if route.Request != nil {
+		op["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": b.schema(reflect.TypeOf(route.Request))},
+			},
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := map[string]any{"description": http.StatusText(status)}
+	if route.Response != nil {
+		contentType := route.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		schema := b.schema(reflect.TypeOf(route.Response))
+		if _, ok := route.Response.([]byte); ok && contentType != "application/json" {
+			schema = map[string]any{"type": "string", "format": "binary"}
+		}
+		response["content"] = map[string]any{
+			contentType: map[string]any{"schema": schema},
+		}
+	}
+	op["responses"] = map[string]any{
+		strconv.Itoa(status): response,
+		"default": map[string]any{
+			"description": "Error",
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": b.schema(reflect.TypeOf(ErrorResponse{}))},
+			},
+		},
+	}
+	return op
+}
+
+// schema возвращает схему типа; именованные структуры выносятся в components
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return map[string]any{"type": "string", "format": "date-time"}
+	case reflect.TypeOf(time.Duration(0)):
+		return map[string]any{"type": "integer", "format": "int64", "description": "nanoseconds"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := b.schema(t.Elem())
+		if _, ok := schema["$ref"]; ok {
+			// В OpenAPI 3.0 соседние с $ref ключи игнорируются
+			return map[string]any{"allOf": []any{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Uint:
+		return map[string]any{"type": "integer"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32:
+		return map[string]any{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]any{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := b.componentName(t)
+		if _, ok := b.schemas[name]; !ok {
+			// Заглушка до построения защищает от бесконечной рекурсии на циклических типах
+			b.schemas[name] = map[string]any{}
+			b.schemas[name] = b.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]any{}
+	}
+}
+
+// structSchema строит схему объекта по экспортируемым полям и их тегам json.
+// Поля без omitempty считаются обязательными, встроенные структуры раскрываются.
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	b.addFields(t, properties, &required)
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (b *schemaBuilder) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.addFields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = b.schema(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// componentName возвращает имя схемы типа; при совпадении имен типов из разных
+// пакетов к имени добавляется пакет
+func (b *schemaBuilder) componentName(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if existing, ok := b.types[name]; ok && existing != t {
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	b.types[name] = t
+	b.names[t] = name
+	return name
+}
+
+// operationID строит идентификатор операции из метода и пути: GET /jobs/{id} → getJobsId
+func operationID(route Route) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(route.Method))
+	for _, part := range strings.FieldsFunc(route.Path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '.'
+	}) {
+		id.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return id.String()
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestOpenAPIDocumentMatchesRoutes проверяет, что документ описывает каждый
+// зарегистрированный маршрут, а типы тел запросов и ответов — схемы в components
+func TestOpenAPIDocumentMatchesRoutes(t *testing.T) {
+	h := NewHandler(nil, nil)
+	routes := h.Routes()
+	doc := OpenAPIDocument(routes, APIVersionPrefix)
+
+	paths := doc["paths"].(map[string]map[string]any)
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+
+	// Шаблоны регистрируются в ServeMux без конфликтов
+	mux := http.NewServeMux()
+	Register(mux, APIVersionPrefix, routes, nil)
+
+	for _, route := range routes {
+		name := route.Method + " " + route.Path
+		routePath := pathParamPattern.ReplaceAllString(APIVersionPrefix+route.Path, "{$1}")
+		item, ok := paths[routePath]
+		if !ok {
+			t.Errorf("%s: path %s is missing from the document", name, routePath)
+			continue
+		}
+		op, ok := item[strings.ToLower(route.Method)].(map[string]any)
+		if !ok {
+			t.Errorf("%s: operation is missing from the document", name)
+			continue
+		}
+
+		if route.Request != nil {
+			schema := op["requestBody"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)
+			checkComponent(t, name+" request", reflect.TypeOf(route.Request), schema, schemas)
+		}
+		if route.Response != nil {
+			status := route.Status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			response := op["responses"].(map[string]any)[strconv.Itoa(status)].(map[string]any)
+			for _, media := range response["content"].(map[string]any) {
+				schema := media.(map[string]any)["schema"].(map[string]any)
+				checkComponent(t, name+" response", reflect.TypeOf(route.Response), schema, schemas)
+			}
+		}
+	}
+
+	// Каждая ссылка в документе указывает на существующую схему
+	walkRefs(doc, func(ref string) {
+		if _, ok := schemas[strings.TrimPrefix(ref, "#/components/schemas/")]; !ok || !strings.HasPrefix(ref, "#/components/schemas/") {
+			t.Errorf("unresolved $ref %s", ref)
+		}
+	})
+}
+
+// checkComponent проверяет, что схема именованной структуры (или среза таких структур)
+// ссылается на схему в components
+func checkComponent(t *testing.T, name string, typ reflect.Type, schema map[string]any, schemas map[string]any) {
+	t.Helper()
+	for typ.Kind() == reflect.Slice || typ.Kind() == reflect.Pointer {
+		if typ.Kind() == reflect.Slice {
+			if typ.Elem().Kind() == reflect.Uint8 {
+				return
+			}
+			items, ok := schema["items"].(map[string]any)
+			if !ok {
+				t.Errorf("%s: %s has no items schema", name, typ)
+				return
+			}
+			schema = items
+		}
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || typ.Name() == "" {
+		return
+	}
+
+	ref, _ := schema["$ref"].(string)
+	if ref == "" {
+		t.Errorf("%s: %s is not a component schema reference", name, typ)
+		return
+	}
+	component, ok := schemas[strings.TrimPrefix(ref, "#/components/schemas/")].(map[string]any)
+	if !ok {
+		t.Errorf("%s: %s does not resolve", name, ref)
+		return
+	}
+	if component["type"] != "object" {
+		t.Errorf("%s: component %s is not an object schema", name, ref)
+	}
+}
+
+func walkRefs(value any, visit func(ref string)) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if ref, ok := item.(string); ok && key == "$ref" {
+				visit(ref)
+				continue
+			}
+			walkRefs(item, visit)
+		}
+	case map[string]map[string]any:
+		for _, item := range v {
+			walkRefs(item, visit)
+		}
+	case []any:
+		for _, item := range v {
+			walkRefs(item, visit)
+		}
+	}
+}
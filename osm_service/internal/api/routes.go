@@ -0,0 +1,295 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"osm_service/internal/domain/model"
+	"osm_service/internal/jobs"
+	"osm_service/internal/mvt"
+)
+
+// APIVersionPrefix — префикс текущей версии HTTP API
+const APIVersionPrefix = "/api/v1"
+
+// LegacyPrefix — префикс маршрутов без версии, оставленных для существующих клиентов
+const LegacyPrefix = "/api"
+
+// Route описывает эндпоинт API. По таблице маршрутов регистрируются обработчики
+// и строится OpenAPI документ, поэтому спецификация не расходится с кодом.
+type Route struct {
+	Method      string
+	Path        string // шаблон http.ServeMux без префикса версии, например "/jobs/{id}"
+	Summary     string
+	Handler     http.HandlerFunc
+	Query       []QueryParam
+	Request     any    // значение типа тела запроса, nil — запрос без тела
+	Response    any    // значение типа тела ответа, nil — ответ без тела
+	Status      int    // код успешного ответа, по умолчанию 200
+	ContentType string // тип ответа, по умолчанию application/json
+}
+
+// QueryParam — параметр строки запроса
+type QueryParam struct {
+	Name        string
+	Description string
+	Required    bool
+	Type        string // тип схемы OpenAPI, по умолчанию string
+}
+
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// Health сообщает, что сервис запущен
+func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(HealthResponse{Status: "ok"})
+}
+
+// Routes возвращает таблицу маршрутов API
+func (h *Handler) Routes() []Route {
+	return []Route{
+		{
+			Method:   http.MethodGet,
+			Path:     "/health",
+			Summary:  "Service health check",
+			Handler:  h.Health,
+			Response: HealthResponse{},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/openapi.json",
+			Summary:  "OpenAPI document of this API version",
+			Handler:  h.OpenAPI,
+			Response: map[string]any{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/predict",
+			Summary: "Predict activity of objects of a category in an area",
+			Handler: h.Predict,
+			Query: []QueryParam{
+				{Name: "explain", Type: "boolean", Description: "Include feature values and contributions"},
+				{Name: "ensemble", Type: "boolean", Description: "Combine all registered models covering the area"},
+				{Name: "format", Description: "\"geojson\" returns a GeoJSON FeatureCollection, as does Accept: application/geo+json"},
+			},
+			Request:  PredictRequest{},
+			Response: model.Prediction{},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/predict/batch",
+			Summary:     "Predict many areas concurrently; streams one JSON result per line as areas finish",
+			Handler:     h.PredictBatch,
+			Request:     BatchPredictRequest{},
+			Response:    BatchPredictResult{},
+			ContentType: ndjsonMediaType,
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/compare",
+			Summary:  "Compare areas of a category side by side: features, predictions and rank by activity",
+			Handler:  h.Compare,
+			Request:  CompareRequest{},
+			Response: CompareResponse{},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/training",
+			Summary:  "Start a background job building a training dataset",
+			Handler:  h.Training,
+			Request:  TrainingRequest{},
+			Response: TrainingResponse{},
+			Status:   http.StatusAccepted,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/datasets/{name}",
+			Summary: "Dataset file; GeoJSON polygons of clusters with Accept: application/geo+json",
+			Handler: h.Dataset,
+			Query: []QueryParam{
+				{Name: "format", Description: "\"geojson\" returns a GeoJSON FeatureCollection"},
+			},
+			Response: model.Dataset{},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/tiles/{layer}/{z}/{x}/{y}",
+			Summary: "Vector tile of cluster cells (layer clusters) or objects of a category (layer pois); {y} ends with .mvt",
+			Handler: h.Tile,
+			Query: []QueryParam{
+				{Name: "dataset", Description: "Dataset file name, required for the clusters layer"},
+				{Name: "shop_type", Description: "Category; required for pois, adds predicted activity_level to clusters"},
+			},
+			Response:    []byte{},
+			ContentType: mvt.MediaType,
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/jobs/{id}",
+			Summary:  "Job state and progress",
+			Handler:  h.Jobs,
+			Response: jobs.Status{},
+		},
+		{
+			Method:   http.MethodDelete,
+			Path:     "/jobs/{id}",
+			Summary:  "Cancel a job",
+			Handler:  h.Jobs,
+			Response: jobs.Status{},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/jobs/{id}/resume",
+			Summary:  "Resume an interrupted dataset build from its checkpoint",
+			Handler:  h.ResumeJob,
+			Response: jobs.Status{},
+			Status:   http.StatusAccepted,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/jobs/{id}/events",
+			Summary:     "Stream of job events (Server-Sent Events)",
+			Handler:     h.JobEvents,
+			Response:    jobs.Event{},
+			ContentType: "text/event-stream",
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/models",
+			Summary: "List registered models",
+			Handler: h.Models,
+			Query: []QueryParam{
+				{Name: "category", Description: "Filter by category"},
+				{Name: "status", Description: "Filter by status: candidate, active or retired"},
+				{Name: "source", Description: "\"ml\" lists models of the ML service instead of the registry"},
+			},
+			Response: []model.RegisteredModel{},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/models",
+			Summary:  "Register a model",
+			Handler:  h.Models,
+			Request:  RegisterModelRequest{},
+			Response: model.RegisteredModel{},
+			Status:   http.StatusCreated,
+		},
+		{
+			Method:   http.MethodPatch,
+			Path:     "/models/{name}",
+			Summary:  "Change the status of a registered model",
+			Handler:  h.UpdateModel,
+			Request:  UpdateModelRequest{},
+			Response: model.RegisteredModel{},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/models/lookup",
+			Summary: "Active model of a category that best covers an area",
+			Handler: h.LookupModel,
+			Query: []QueryParam{
+				{Name: "category", Required: true},
+				{Name: "bbox", Required: true, Description: "Area \"minLat,minLon,maxLat,maxLon\""},
+			},
+			Response: model.RegisteredModel{},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/evaluation/compare",
+			Summary:  "Accuracy of models against ground truth and shadow divergence",
+			Handler:  h.CompareModels,
+			Response: model.ModelComparison{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/evaluation/ground-truth",
+			Summary: "Save actual openings and closures for a year",
+			Handler: h.GroundTruth,
+			Request: model.GroundTruth{},
+			Status:  http.StatusNoContent,
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/analysis/changepoints",
+			Summary:  "Change points of the object count series",
+			Handler:  h.ChangePoints,
+			Request:  ChangePointRequest{},
+			Response: ChangePointResponse{},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/analysis/impact",
+			Summary:  "Impact of new subway stations and primary roads",
+			Handler:  h.Impact,
+			Request:  ImpactRequest{},
+			Response: ImpactResponse{},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/backtest",
+			Summary:  "Accuracy of predictions against history",
+			Handler:  h.Backtest,
+			Request:  BacktestRequest{},
+			Response: model.BacktestResult{},
+		},
+	}
+}
+
+// Register регистрирует маршруты в mux под префиксом prefix. Шаблоны включают метод,
+// поэтому на запрос другим методом ServeMux сам отвечает 405 с заголовком Allow
+// (в формате ErrorResponse, если mux обернут WithErrorResponses).
+// Каждому запросу присваивается идентификатор для ответов с ошибкой, wrap оборачивает
+// каждый обработчик (например, логированием) и может быть nil.
+func Register(mux *http.ServeMux, prefix string, routes []Route, wrap func(http.HandlerFunc) http.HandlerFunc) {
+	for _, route := range routes {
+		handler := withRequestID(route.Handler)
+		if wrap != nil {
+			handler = wrap(handler)
+		}
+		mux.HandleFunc(route.Method+" "+prefix+route.Path, handler)
+	}
+}
+
+// WithErrorResponses оборачивает mux: на запрос без подходящего маршрута ServeMux сам
+// отвечает 404 или 405 текстом, а спецификация объявляет для ошибок ErrorResponse.
+// Такие ответы заменяются на ErrorResponse, остальные (например, редиректы) не меняются.
+func WithErrorResponses(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		withRequestID(func(w http.ResponseWriter, r *http.Request) {
+			mux.ServeHTTP(&muxErrorWriter{ResponseWriter: w, r: r}, r)
+		})(w, r)
+	})
+}
+
+// muxErrorWriter заменяет текстовые ответы 404 и 405 ServeMux на ErrorResponse
+type muxErrorWriter struct {
+	http.ResponseWriter
+	r        *http.Request
+	replaced bool
+}
+
+func (w *muxErrorWriter) WriteHeader(status int) {
+	switch status {
+	case http.StatusNotFound:
+		w.replaced = true
+		writeError(w.ResponseWriter, w.r, status, CodeNotFound, "Route not found", nil)
+	case http.StatusMethodNotAllowed:
+		// Заголовок Allow уже выставлен ServeMux
+		w.replaced = true
+		writeMethodNotAllowed(w.ResponseWriter, w.r)
+	default:
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *muxErrorWriter) Write(data []byte) (int, error) {
+	if w.replaced {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithErrorResponses(t *testing.T) {
+	mux := http.NewServeMux()
+	Register(mux, APIVersionPrefix, NewHandler(nil, nil).Routes(), nil)
+	server := httptest.NewServer(WithErrorResponses(mux))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		code   string
+	}{
+		{"unknown route", http.MethodGet, "/api/v1/unknown", http.StatusNotFound, CodeNotFound},
+		{"wrong method", http.MethodDelete, "/api/v1/predict", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, server.URL+tt.path, nil)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", contentType)
+			}
+			var body ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode ErrorResponse: %v", err)
+			}
+			if body.Code != tt.code || body.RequestID == "" {
+				t.Errorf("unexpected error response %+v", body)
+			}
+			if body.RequestID != resp.Header.Get(requestIDHeader) {
+				t.Errorf("request_id %q does not match %s header %q", body.RequestID, requestIDHeader, resp.Header.Get(requestIDHeader))
+			}
+		})
+	}
+
+	t.Run("allow header", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, server.URL+"/api/v1/predict", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.Header.Get("Allow") == "" {
+			t.Errorf("405 response has no Allow header")
+		}
+	})
+
+	t.Run("matched route", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/api/v1/health")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var body HealthResponse
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Status != "ok" {
+			t.Errorf("health = %+v, %v", body, err)
+		}
+	})
+}
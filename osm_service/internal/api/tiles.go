@@ -0,0 +1,345 @@
+package api
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"osm_service/internal/domain/model"
+	"osm_service/internal/mvt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Слои векторных тайлов
+const (
+	tileLayerClusters = "clusters"
+	tileLayerPOIs     = "pois"
+)
+
+// minPOIZoom — на меньших масштабах тайл слишком велик для запроса объектов к Overpass
+const minPOIZoom = 13
+
+// Кэш готовых тайлов. Точки объектов берутся из OSM и устаревают, поэтому у тайлов есть срок жизни.
+const (
+	tileCacheSize = 4096
+	tileCacheTTL  = 10 * time.Minute
+)
+
+// datasetCacheSize ограничивает число разобранных датасетов в памяти: датасет большой
+// области занимает десятки мегабайт
+const datasetCacheSize = 8
+
+// Tile отдает векторный тайл /tiles/{layer}/{z}/{x}/{y}.mvt. Слой clusters строится
+// по ячейкам сохраненного датасета (параметр dataset), слой pois — по объектам
+// категории shop_type из OSM.
+func (h *Handler) Tile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, r)
+		return
+	}
+
+	tile, err := parseTileID(r)
+	if err != nil {
+		writeValidationError(w, r, "tile", err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	shopType := query.Get("shop_type")
+	layer := r.PathValue("layer")
+	key := fmt.Sprintf("%s/%d/%d/%d?shop_type=%s", layer, tile.Z, tile.X, tile.Y, shopType)
+
+	var build func() (*mvt.Layer, error)
+	switch layer {
+	case tileLayerClusters:
+		name := query.Get("dataset")
+		if !validDatasetName(name) {
+			writeValidationError(w, r, "dataset", datasetNameMessage)
+			return
+		}
+		dataset, modTime, err := h.datasets.load(name)
+		if errors.Is(err, os.ErrNotExist) {
+			writeDatasetNotFound(w, r)
+			return
+		}
+		if err != nil {
+			writeServiceError(w, r, err, "reading dataset")
+			return
+		}
+		// Время изменения в ключе сбрасывает кэш, когда датасет перестроен
+		key += fmt.Sprintf("&dataset=%s@%d", name, modTime.UnixNano())
+		build = func() (*mvt.Layer, error) {
+			return h.clusterLayer(tile, dataset, shopType)
+		}
+	case tileLayerPOIs:
+		if shopType == "" {
+			writeValidationError(w, r, "shop_type", "is required")
+			return
+		}
+		build = func() (*mvt.Layer, error) {
+			return h.poiLayer(r, tile, shopType)
+		}
+	default:
+		writeValidationError(w, r, "layer", fmt.Sprintf("expected %q or %q, got %q", tileLayerClusters, tileLayerPOIs, layer))
+		return
+	}
+
+	data, ok := h.tiles.get(key)
+	if !ok {
+		tileLayer, err := build()
+		if err != nil {
+			writeServiceError(w, r, err, "building tile")
+			return
+		}
+		data, err = mvt.Encode(tileLayer)
+		if err != nil {
+			writeServiceError(w, r, err, "encoding tile")
+			return
+		}
+		h.tiles.put(key, data)
+	}
+
+	w.Header().Set("Content-Type", mvt.MediaType)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(tileCacheTTL.Seconds())))
+	w.Write(data)
+}
+
+// parseTileID читает адрес тайла из пути; y задается с расширением .mvt
+func parseTileID(r *http.Request) (mvt.TileID, error) {
+	y, ok := strings.CutSuffix(r.PathValue("y"), ".mvt")
+	if !ok {
+		return mvt.TileID{}, fmt.Errorf("expected /{z}/{x}/{y}.mvt")
+	}
+
+	var tile mvt.TileID
+	var err error
+	if tile.Z, err = strconv.Atoi(r.PathValue("z")); err != nil {
+		return mvt.TileID{}, fmt.Errorf("invalid z: %q", r.PathValue("z"))
+	}
+	if tile.X, err = strconv.Atoi(r.PathValue("x")); err != nil {
+		return mvt.TileID{}, fmt.Errorf("invalid x: %q", r.PathValue("x"))
+	}
+	if tile.Y, err = strconv.Atoi(y); err != nil {
+		return mvt.TileID{}, fmt.Errorf("invalid y: %q", y)
+	}
+	return tile, tile.Validate()
+}
+
+// clusterLayer строит ячейки кластеров с признаками последнего года датасета:
+// плотность объектов, темп роста (доля новых минус доля закрытых) и, если есть
+// экспортированная модель для shop_type, предсказанный уровень активности
+func (h *Handler) clusterLayer(tile mvt.TileID, dataset model.Dataset, shopType string) (*mvt.Layer, error) {
+	layer := mvt.NewLayer(tileLayerClusters)
+	for _, cluster := range dataset.Clusters {
+		bounds, err := model.ParseBounds(cluster.Bbox)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %d: %w", cluster.Index, err)
+		}
+
+		properties := map[string]any{
+			"index": cluster.Index,
+			"bbox":  cluster.Bbox,
+		}
+		if year, features, ok := latestFeatures(cluster); ok {
+			properties["year"] = year
+			properties["total_objects"] = features.TotalObjects
+			properties["object_density"] = features.ObjectDensity
+			properties["growth_rate"] = features.NewObjectRate - features.ClosureRate
+			if shopType != "" {
+				if activity, ok := h.service.ClusterActivity(shopType, bounds, features); ok {
+					properties["activity_level"] = activity
+				}
+			}
+		}
+
+		layer.AddBounds(tile, bounds, uint64(cluster.Index)+1, properties)
+	}
+	return layer, nil
+}
+
+// latestFeatures возвращает признаки кластера за последний год с данными
+func latestFeatures(cluster model.DatasetCluster) (int, model.FeatureVector, bool) {
+	for i := len(cluster.Data) - 1; i >= 0; i-- {
+		year := cluster.Data[i]
+		if len(year.Data) > 0 {
+			return year.Year, year.Data[len(year.Data)-1], true
+		}
+	}
+	return 0, model.FeatureVector{}, false
+}
+
+// poiLayer строит точки объектов категории в области тайла
+func (h *Handler) poiLayer(r *http.Request, tile mvt.TileID, shopType string) (*mvt.Layer, error) {
+	layer := mvt.NewLayer(tileLayerPOIs)
+	if tile.Z < minPOIZoom {
+		return layer, nil
+	}
+
+	elements, err := h.service.CommercialObjects(r.Context(), tile.Bounds(), shopType)
+	if err != nil {
+		return nil, err
+	}
+	for _, element := range elements {
+		// Узлы контуров зданий приходят без тегов, это не объекты категории
+		if len(element.Tags) == 0 {
+			continue
+		}
+		properties := map[string]any{"osm_type": element.Type}
+		for _, tag := range []string{"name", "shop", "amenity"} {
+			if value := element.Tags[tag]; value != "" {
+				properties[tag] = value
+			}
+		}
+		layer.AddPoint(tile, element.Lat, element.Lon, uint64(element.ID), properties)
+	}
+	return layer, nil
+}
+
+// tileCache хранит последние построенные тайлы (LRU со сроком жизни)
+type tileCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type tileEntry struct {
+	key     string
+	data    []byte
+	expires time.Time
+}
+
+func newTileCache(capacity int, ttl time.Duration) *tileCache {
+	return &tileCache{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *tileCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*tileEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return entry.data, true
+}
+
+func (c *tileCache) put(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &tileEntry{key: key, data: data, expires: time.Now().Add(c.ttl)}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*tileEntry).key)
+	}
+}
+
+// datasetCache хранит последние разобранные датасеты (LRU), чтобы не читать файл
+// на каждый тайл. Датасет перечитывается, если файл изменился.
+type datasetCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type cachedDataset struct {
+	name    string
+	modTime time.Time
+	dataset model.Dataset
+}
+
+func newDatasetCache(capacity int) *datasetCache {
+	return &datasetCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// load возвращает датасет name из datasetsDir и время изменения файла. Файл читается
+// и разбирается без блокировки, чтобы большой датасет не задерживал тайлы других датасетов;
+// при одновременных промахах один файл может быть прочитан несколько раз.
+func (c *datasetCache) load(name string) (model.Dataset, time.Time, error) {
+	path := filepath.Join(datasetsDir, name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return model.Dataset{}, time.Time{}, err
+	}
+
+	if cached, ok := c.get(name, info.ModTime()); ok {
+		return cached.dataset, cached.modTime, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return model.Dataset{}, time.Time{}, err
+	}
+	var dataset model.Dataset
+	if err := json.Unmarshal(data, &dataset); err != nil {
+		return model.Dataset{}, time.Time{}, fmt.Errorf("failed to decode dataset %s: %w", name, err)
+	}
+	c.put(&cachedDataset{name: name, modTime: info.ModTime(), dataset: dataset})
+	return dataset, info.ModTime(), nil
+}
+
+// get возвращает датасет из кэша, если он прочитан из файла с временем изменения modTime
+func (c *datasetCache) get(name string, modTime time.Time) (*cachedDataset, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[name]
+	if !ok {
+		return nil, false
+	}
+	cached := element.Value.(*cachedDataset)
+	if !cached.modTime.Equal(modTime) {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return cached, true
+}
+
+func (c *datasetCache) put(entry *cachedDataset) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[entry.name]; ok {
+		// Более новую версию, прочитанную параллельно, не заменяем старой
+		if element.Value.(*cachedDataset).modTime.After(entry.modTime) {
+			return
+		}
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[entry.name] = c.order.PushFront(entry)
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedDataset).name)
+	}
+}
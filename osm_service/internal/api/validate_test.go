@@ -0,0 +1,99 @@
+package api
+
+import (
+	"errors"
+	"osm_service/internal/core"
+	"slices"
+	"testing"
+)
+
+// validationFields возвращает поля ошибки проверки в порядке добавления
+func validationFields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var validation *core.ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("expected *core.ValidationError, got %T: %v", err, err)
+	}
+	fields := make([]string, 0, len(validation.Fields))
+	for _, field := range validation.Fields {
+		fields = append(fields, field.Field)
+	}
+	return fields
+}
+
+func TestRequestValidateReportsAllFields(t *testing.T) {
+	const bbox = "55.78,37.58,55.80,37.60"
+
+	tests := []struct {
+		name     string
+		validate func() error
+		fields   []string
+	}{
+		{
+			name: "change points valid",
+			validate: func() error {
+				_, err := ChangePointRequest{BBox: bbox, ShopType: "cafe", StartDate: "2019-01-01", EndDate: "2023-01-01"}.Validate()
+				return err
+			},
+		},
+		{
+			name: "change points invalid",
+			validate: func() error {
+				_, err := ChangePointRequest{BBox: "55.78", StartDate: "2019", EndDate: "2023-01-01", Penalty: -1}.Validate()
+				return err
+			},
+			fields: []string{"bbox", "shop_type", "start_date", "penalty"},
+		},
+		{
+			name: "impact end before start",
+			validate: func() error {
+				_, err := ImpactRequest{BBox: bbox, ShopType: "cafe", StartDate: "2023-01-01", EndDate: "2019-01-01", RingRadius: -5}.Validate()
+				return err
+			},
+			fields: []string{"end_date", "ring_radius"},
+		},
+		{
+			name: "backtest valid",
+			validate: func() error {
+				_, err := BacktestRequest{BBox: bbox, ShopTypes: []string{"cafe"}, ClusterSize: 1, CutoffDate: "2022-01-01"}.Validate()
+				return err
+			},
+		},
+		{
+			name: "backtest invalid",
+			validate: func() error {
+				_, err := BacktestRequest{CutoffDate: "01.01.2022", HorizonYears: -1}.Validate()
+				return err
+			},
+			fields: []string{"bbox", "shop_types", "cluster_size", "cutoff_date", "horizon_years"},
+		},
+		{
+			name: "backtest too many clusters",
+			validate: func() error {
+				_, err := BacktestRequest{BBox: "55.0,37.0,56.0,38.0", ShopTypes: []string{"cafe"}, ClusterSize: 1, CutoffDate: "2022-01-01"}.Validate()
+				return err
+			},
+			fields: []string{"cluster_size"},
+		},
+		{
+			name: "register model invalid",
+			validate: func() error {
+				_, err := RegisterModelRequest{TrainStart: "2019", TrainEnd: "2023-12-31", Coverage: "bad"}.Validate()
+				return err
+			},
+			fields: []string{"train_start", "name", "category", "coverage"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := validationFields(t, tt.validate())
+			if !slices.Equal(fields, tt.fields) {
+				t.Errorf("expected invalid fields %v, got %v", tt.fields, fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"osm_service/internal/domain/model"
+	"time"
+)
+
+// DetectChangePoints находит точки перелома в ряду количества объектов кластера
+// и при необходимости сопоставляет их с открытием станций метро
+func (s *PredictionService) DetectChangePoints(
+	ctx context.Context,
+	bounds model.Bounds,
+	startDate time.Time,
+	endDate time.Time,
+	shopType string,
+	detector ChangePointDetector,
+	correlate bool,
+) ([]model.ChangePoint, error) {
+	historical, err := s.GetHistoricalDataForPeriod(ctx, bounds, startDate, endDate, shopType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get historical data: %w", err)
+	}
+
+	points := detector.Detect(historical)
+	if !correlate {
+		return points, nil
+	}
+
+	// Index точки перелома указывает в ряд, упорядоченный по периоду
+	historical = sortByPeriod(historical)
+
+	bbox := fmt.Sprintf("%f,%f,%f,%f", bounds.MinLat, bounds.MinLon, bounds.MaxLat, bounds.MaxLon)
+	for i, point := range points {
+		// Сравниваем станции метро на конец предыдущего периода и на дату перелома
+		change, err := s.subwayChange(ctx, bbox, historical[point.Index-1].Period, point.Period)
+		if err != nil {
+			log.Printf("Warning: failed to correlate change point %s with subway data: %v", point.Period, err)
+			continue
+		}
+		points[i].Infrastructure = change
+	}
+
+	return points, nil
+}
+
+// subwayChange сравнивает станции метро в bbox на две даты в формате "2006-01-02"
+func (s *PredictionService) subwayChange(ctx context.Context, bbox, before, after string) (*model.InfrastructureChange, error) {
+	beforeDate, err := time.Parse("2006-01-02", before)
+	if err != nil {
+		return nil, fmt.Errorf("invalid period %q: %w", before, err)
+	}
+	afterDate, err := time.Parse("2006-01-02", after)
+	if err != nil {
+		return nil, fmt.Errorf("invalid period %q: %w", after, err)
+	}
+
+	stationsBefore, err := s.overpassRepo.GetSubwayData(ctx, bbox, beforeDate.Format("2006-01-02T15:04:05Z"))
+	if err != nil {
+		return nil, err
+	}
+	stationsAfter, err := s.overpassRepo.GetSubwayData(ctx, bbox, afterDate.Format("2006-01-02T15:04:05Z"))
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[int64]struct{})
+	for _, el := range stationsBefore {
+		existing[el.ID] = struct{}{}
+	}
+
+	change := &model.InfrastructureChange{
+		SubwayStationsBefore: len(stationsBefore),
+		SubwayStationsAfter:  len(stationsAfter),
+		NewSubwayStations:    []int64{},
+	}
+	for _, el := range stationsAfter {
+		if _, ok := existing[el.ID]; !ok {
+			change.NewSubwayStations = append(change.NewSubwayStations, el.ID)
+		}
+	}
+
+	return change, nil
+}
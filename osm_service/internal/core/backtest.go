@@ -0,0 +1,211 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"osm_service/internal/domain/model"
+	"strconv"
+	"time"
+)
+
+// Backtest проверяет предсказания на истории: для каждого кластера и категории признаки
+// строятся только по снимкам OSM до opts.Cutoff, а предсказания на каждый год после
+// Cutoff сравниваются с тем, что произошло на самом деле
+func (s *PredictionService) Backtest(ctx context.Context, opts model.BacktestOptions) (*model.BacktestResult, error) {
+	if opts.TrainYears <= 0 {
+		opts.TrainYears = 3
+	}
+	if opts.HorizonYears <= 0 {
+		opts.HorizonYears = 1
+	}
+	var validation ValidationError
+	if err := ValidateClusterSize(opts.Bounds, opts.ClusterSize, MaxClusters); err != nil {
+		validation.Add("cluster_size", "%v", err)
+	}
+	if len(opts.Categories) == 0 {
+		validation.Add("shop_types", "at least one category is required")
+	}
+	for i, category := range opts.Categories {
+		if category == "" {
+			validation.Add("shop_types", "category %d is empty", i)
+		}
+	}
+	if err := validation.Err(); err != nil {
+		return nil, err
+	}
+
+	evaluationEnd := opts.Cutoff.AddDate(opts.HorizonYears, 0, 0)
+	if evaluationEnd.After(time.Now()) {
+		return nil, invalidField("cutoff_date", "%s is too recent: %d year(s) after it have not passed yet",
+			opts.Cutoff.Format("2006-01-02"), opts.HorizonYears)
+	}
+
+	clusters := GenerateClusters(opts.Bounds, opts.ClusterSize)
+	result := &model.BacktestResult{
+		Cutoff:        opts.Cutoff.Format("2006-01-02"),
+		EvaluationEnd: evaluationEnd.Format("2006-01-02"),
+	}
+
+	for _, category := range opts.Categories {
+		var categoryObservations []model.BacktestObservation
+		summary := model.CategoryBacktest{Category: category, Clusters: len(clusters)}
+
+		for i, cluster := range clusters {
+			clusterResult := model.ClusterBacktest{
+				Index:    i,
+				BBox:     fmt.Sprintf("%f,%f,%f,%f", cluster.MinLat, cluster.MinLon, cluster.MaxLat, cluster.MaxLon),
+				Category: category,
+			}
+
+			observations, err := s.backtestCluster(ctx, cluster, category, opts)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if err != nil {
+				log.Printf("Warning: backtest failed for cluster %d (%s): %v", i, category, err)
+				clusterResult.Error = err.Error()
+				summary.Failed++
+			} else {
+				metrics := backtestMetrics(observations)
+				clusterResult.Observations = observations
+				clusterResult.Metrics = &metrics
+				categoryObservations = append(categoryObservations, observations...)
+			}
+
+			result.Clusters = append(result.Clusters, clusterResult)
+		}
+
+		summary.Metrics = backtestMetrics(categoryObservations)
+		result.Categories = append(result.Categories, summary)
+	}
+
+	return result, nil
+}
+
+// backtestCluster строит признаки на дату Cutoff и сверяет предсказания с фактом по годам
+func (s *PredictionService) backtestCluster(
+	ctx context.Context,
+	bounds model.Bounds,
+	category string,
+	opts model.BacktestOptions,
+) ([]model.BacktestObservation, error) {
+	startDate := opts.Cutoff.AddDate(-opts.TrainYears, 0, 0)
+	bbox := fmt.Sprintf("%f,%f,%f,%f", bounds.MinLat, bounds.MinLon, bounds.MaxLat, bounds.MaxLon)
+
+	historical, err := s.GetHistoricalDataForPeriod(ctx, bounds, startDate, opts.Cutoff, category)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get historical data: %w", err)
+	}
+
+	actual, err := s.GetHistoricalDataForPeriod(ctx, bounds, opts.Cutoff, opts.Cutoff.AddDate(opts.HorizonYears, 0, 0), category)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get actual data: %w", err)
+	}
+	if len(actual) < opts.HorizonYears+1 {
+		return nil, fmt.Errorf("expected %d snapshots after cutoff, got %d", opts.HorizonYears+1, len(actual))
+	}
+
+	// Признаки считаются так же, как при предсказании, но по состоянию OSM на Cutoff
+	var features *model.FeatureVector
+	current, err := s.GetDataByDate(ctx, bounds, category, opts.Cutoff)
+	if err == nil {
+		var row model.FeatureVector
+		row, err = s.featureRow(ctx, current, bounds, historical, startDate, opts.Cutoff)
+		features = &row
+	}
+	if err != nil {
+		log.Printf("Warning: failed to calculate backtest features for bbox=%s, using forecast: %v", bbox, err)
+		features = nil
+	}
+
+	observations := make([]model.BacktestObservation, 0, opts.HorizonYears)
+	for year := 1; year <= opts.HorizonYears; year++ {
+		predictionYear := strconv.Itoa(opts.Cutoff.Year() + year)
+
+		var prediction *model.Prediction
+		if features != nil {
+			prediction, err = s.predict(ctx, model.PredictionQuery{
+				BBox:           bbox,
+				ShopType:       category,
+				PredictionYear: predictionYear,
+				TrainPeriod:    fmt.Sprintf("%d-%d", startDate.Year(), opts.Cutoff.Year()),
+				Features:       features,
+			}, bounds)
+			if err != nil {
+				log.Printf("Warning: backtest prediction failed for bbox=%s, using forecast: %v", bbox, err)
+			}
+		}
+		if prediction == nil {
+			prediction, err = s.forecastPrediction(historical, startDate, opts.Cutoff, predictionYear)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get prediction for %s: %w", predictionYear, err)
+			}
+		}
+
+		// Фактическая активность считается по той же формуле, что и целевая переменная обучения
+		temporalAnalyzer := TemporalAnalyzer{}
+		temporal := temporalAnalyzer.Analyze([]model.HistoricalData{actual[year-1], actual[year]}, 1)
+
+		observations = append(observations, model.BacktestObservation{
+			Year:            opts.Cutoff.Year() + year,
+			ModelUsed:       prediction.ModelUsed,
+			ActivityLevel:   prediction.ActivityLevel,
+			PredictedNew:    prediction.PredictedNew,
+			PredictedClosed: prediction.PredictedClosed,
+			ActualActivity:  activityLevel(temporal),
+			ActualNew:       actual[year].NewObjects,
+			ActualClosed:    actual[year].ClosedObjects,
+		})
+	}
+
+	return observations, nil
+}
+
+func backtestMetrics(observations []model.BacktestObservation) model.BacktestMetrics {
+	var activity, activityActual, newPredicted, newActual, closedPredicted, closedActual []float64
+	for _, o := range observations {
+		activity = append(activity, o.ActivityLevel)
+		activityActual = append(activityActual, o.ActualActivity)
+		newPredicted = append(newPredicted, float64(o.PredictedNew))
+		newActual = append(newActual, float64(o.ActualNew))
+		closedPredicted = append(closedPredicted, float64(o.PredictedClosed))
+		closedActual = append(closedActual, float64(o.ActualClosed))
+	}
+
+	return model.BacktestMetrics{
+		ActivityLevel:   errorMetrics(activity, activityActual),
+		PredictedNew:    errorMetrics(newPredicted, newActual),
+		PredictedClosed: errorMetrics(closedPredicted, closedActual),
+	}
+}
+
+// errorMetrics считает MAE, RMSE и MAPE (в процентах) предсказаний относительно факта
+func errorMetrics(predicted, actual []float64) model.ErrorMetrics {
+	metrics := model.ErrorMetrics{Count: len(predicted)}
+	if len(predicted) == 0 {
+		return metrics
+	}
+
+	var absSum, sqSum, pctSum float64
+	var pctCount int
+	for i := range predicted {
+		diff := predicted[i] - actual[i]
+		absSum += math.Abs(diff)
+		sqSum += diff * diff
+		if actual[i] != 0 {
+			pctSum += math.Abs(diff / actual[i])
+			pctCount++
+		}
+	}
+
+	n := float64(len(predicted))
+	metrics.MAE = absSum / n
+	metrics.RMSE = math.Sqrt(sqSum / n)
+	if pctCount > 0 {
+		mape := pctSum / float64(pctCount) * 100
+		metrics.MAPE = &mape
+	}
+	return metrics
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"osm_service/internal/domain/model"
+	"sync"
+)
+
+// MaxBatchAreas ограничивает число областей в одном пакетном предсказании
+const MaxBatchAreas = 1000
+
+// SetBatchConcurrency задает число областей пакетного предсказания и сравнения областей,
+// обрабатываемых одновременно. Как и при построении датасета, значение ограничивается
+// числом слотов Overpass. 0 — по числу слотов.
+func (s *PredictionService) SetBatchConcurrency(workers int) {
+	s.batchConcurrency = workers
+}
+
+// PredictBatch получает предсказания для областей bboxes параллельно. Результат каждой
+// области передается в emit по мере готовности (вызовы emit не пересекаются), ошибка
+// области не прерывает пакет. После отмены ctx оставшиеся области не обрабатываются.
+func (s *PredictionService) PredictBatch(
+	ctx context.Context,
+	bboxes []string,
+	shopType string,
+	predictionYear string,
+	trainPeriod string,
+	emit func(index int, prediction *model.Prediction, err error),
+) {
+	var emitMu sync.Mutex
+	s.forEachArea(ctx, len(bboxes), func(i int) {
+		prediction, err := s.GetPrediction(ctx, bboxes[i], shopType, predictionYear, trainPeriod)
+		if ctx.Err() != nil {
+			return
+		}
+		emitMu.Lock()
+		emit(i, prediction, err)
+		emitMu.Unlock()
+	})
+}
+
+// forEachArea вызывает process для индексов 0..n-1 пулом воркеров в пределах слотов Overpass.
+// После отмены ctx новые индексы не выдаются.
+func (s *PredictionService) forEachArea(ctx context.Context, n int, process func(i int)) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < s.overpassWorkers(s.batchConcurrency); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				process(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(indexes)
+	wg.Wait()
+}
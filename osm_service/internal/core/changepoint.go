@@ -0,0 +1,196 @@
+package core
+
+import (
+	"math"
+	"osm_service/internal/domain/model"
+	"sort"
+)
+
+// ChangePointDetector ищет точки смены уровня в ряду количества объектов методом PELT
+type ChangePointDetector struct {
+	// Penalty — штраф за добавление точки перелома. Если не задан, используется
+	// BIC-подобный штраф 2·σ²·ln(n) с робастной оценкой дисперсии шума.
+	Penalty float64
+	// MinSegment — минимальная длина сегмента в периодах
+	MinSegment int
+}
+
+// Detect ищет точки перелома в ряду data. Ряд упорядочивается по периоду в копии,
+// data не меняется; Index точки — номер периода в упорядоченном ряду (см. sortByPeriod).
+func (d *ChangePointDetector) Detect(data []model.HistoricalData) []model.ChangePoint {
+	data = sortByPeriod(data)
+
+	series := make([]float64, len(data))
+	for i, item := range data {
+		series[i] = float64(item.TotalObjects)
+	}
+
+	breaks := d.detectBreaks(series)
+
+	points := make([]model.ChangePoint, 0, len(breaks))
+	prev := 0
+	for i, b := range breaks {
+		next := len(series)
+		if i+1 < len(breaks) {
+			next = breaks[i+1]
+		}
+
+		before := mean(series[prev:b])
+		after := mean(series[b:next])
+
+		point := model.ChangePoint{
+			Index:      b,
+			Period:     data[b].Period,
+			MeanBefore: before,
+			MeanAfter:  after,
+			Magnitude:  after - before,
+		}
+		if before > 0 {
+			point.RelativeJump = (after - before) / before
+		}
+		points = append(points, point)
+		prev = b
+	}
+
+	return points
+}
+
+// sortByPeriod возвращает копию ряда, упорядоченную по периоду
+func sortByPeriod(data []model.HistoricalData) []model.HistoricalData {
+	sorted := append([]model.HistoricalData(nil), data...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Period < sorted[j].Period
+	})
+	return sorted
+}
+
+// detectBreaks возвращает индексы начала новых сегментов (без нуля)
+func (d *ChangePointDetector) detectBreaks(series []float64) []int {
+	n := len(series)
+	minSize := d.MinSegment
+	if minSize < 1 {
+		minSize = 1
+	}
+	if n < 2*minSize {
+		return nil
+	}
+
+	penalty := d.Penalty
+	if penalty <= 0 {
+		sigma2 := noiseVariance(series)
+		if sigma2 == 0 {
+			return nil
+		}
+		penalty = 2 * sigma2 * math.Log(float64(n))
+	}
+
+	// Префиксные суммы для расчета стоимости сегмента за O(1)
+	sum := make([]float64, n+1)
+	sumSq := make([]float64, n+1)
+	for i, v := range series {
+		sum[i+1] = sum[i] + v
+		sumSq[i+1] = sumSq[i] + v*v
+	}
+	cost := func(s, t int) float64 {
+		length := float64(t - s)
+		segSum := sum[t] - sum[s]
+		return (sumSq[t] - sumSq[s]) - segSum*segSum/length
+	}
+
+	best := make([]float64, n+1)
+	last := make([]int, n+1)
+	best[0] = -penalty
+	candidates := []int{0}
+
+	for t := minSize; t <= n; t++ {
+		best[t] = math.Inf(1)
+		for _, s := range candidates {
+			if value := best[s] + cost(s, t) + penalty; value < best[t] {
+				best[t] = value
+				last[t] = s
+			}
+		}
+
+		// Отбрасываем кандидатов, которые уже не могут дать оптимум
+		pruned := candidates[:0]
+		for _, s := range candidates {
+			if best[s]+cost(s, t) <= best[t] {
+				pruned = append(pruned, s)
+			}
+		}
+		candidates = pruned
+		if next := t + 1 - minSize; next >= minSize {
+			candidates = append(candidates, next)
+		}
+	}
+
+	var breaks []int
+	for t := last[n]; t > 0; t = last[t] {
+		breaks = append(breaks, t)
+	}
+	sort.Ints(breaks)
+	return breaks
+}
+
+// noiseVariance оценивает дисперсию шума по первым разностям ряда (MAD),
+// чтобы сами скачки уровня не завышали оценку
+func noiseVariance(series []float64) float64 {
+	if len(series) < 2 {
+		return 0
+	}
+
+	diffs := make([]float64, len(series)-1)
+	for i := 1; i < len(series); i++ {
+		diffs[i-1] = series[i] - series[i-1]
+	}
+
+	center := median(diffs)
+	deviations := make([]float64, len(diffs))
+	for i, v := range diffs {
+		deviations[i] = math.Abs(v - center)
+	}
+	sigma := 1.4826 * median(deviations) / math.Sqrt2
+	if sigma > 0 {
+		return sigma * sigma
+	}
+
+	// Для почти постоянных рядов с редкими скачками MAD равен нулю,
+	// поэтому используем обычную дисперсию разностей
+	return variance(diffs) / 2
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values))
+}
+
+func variance(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	m := mean(values)
+	var total float64
+	for _, v := range values {
+		total += (v - m) * (v - m)
+	}
+	return total / float64(len(values)-1)
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
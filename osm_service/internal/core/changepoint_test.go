@@ -0,0 +1,113 @@
+package core
+
+import (
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"osm_service/internal/domain/model"
+)
+
+// levelSeries строит годовой ряд из уровней с чередующимся шумом ±noise
+func levelSeries(noise int, levels ...[2]int) []model.HistoricalData {
+	start := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+	var data []model.HistoricalData
+	for _, level := range levels {
+		for range level[1] {
+			value := level[0] + noise
+			if len(data)%2 == 1 {
+				value = level[0] - noise
+			}
+			data = append(data, model.HistoricalData{
+				Period:       start.AddDate(len(data), 0, 0).Format("2006-01-02"),
+				TotalObjects: value,
+			})
+		}
+	}
+	return data
+}
+
+func TestChangePointDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		detector ChangePointDetector
+		data     []model.HistoricalData
+		breaks   []int
+	}{
+		{
+			name:   "single step",
+			data:   levelSeries(1, [2]int{10, 8}, [2]int{30, 8}),
+			breaks: []int{8},
+		},
+		{
+			name:   "two steps",
+			data:   levelSeries(1, [2]int{10, 6}, [2]int{30, 6}, [2]int{15, 6}),
+			breaks: []int{6, 12},
+		},
+		{
+			name: "no change",
+			data: levelSeries(1, [2]int{20, 16}),
+		},
+		{
+			name: "constant series",
+			data: levelSeries(0, [2]int{20, 10}),
+		},
+		{
+			name:     "series shorter than two segments",
+			detector: ChangePointDetector{MinSegment: 4},
+			data:     levelSeries(1, [2]int{10, 3}, [2]int{30, 4}),
+		},
+		{
+			name:   "small step",
+			data:   levelSeries(1, [2]int{10, 8}, [2]int{14, 8}),
+			breaks: []int{8},
+		},
+		{
+			name:     "explicit penalty hides small step",
+			detector: ChangePointDetector{Penalty: 1000},
+			data:     levelSeries(1, [2]int{10, 8}, [2]int{14, 8}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points := tt.detector.Detect(tt.data)
+			var breaks []int
+			for _, point := range points {
+				breaks = append(breaks, point.Index)
+			}
+			if !slices.Equal(breaks, tt.breaks) {
+				t.Errorf("expected breaks %v, got %v", tt.breaks, breaks)
+			}
+		})
+	}
+}
+
+func TestChangePointDetectStep(t *testing.T) {
+	data := levelSeries(0, [2]int{10, 5}, [2]int{25, 5})
+	data[2].TotalObjects = 11 // шум, без которого дисперсия равна нулю
+	slices.Reverse(data)
+	reversed := slices.Clone(data)
+
+	detector := ChangePointDetector{}
+	points := detector.Detect(data)
+	if !reflect.DeepEqual(data, reversed) {
+		t.Error("expected Detect to leave the input order unchanged")
+	}
+	if len(points) != 1 {
+		t.Fatalf("expected one change point, got %+v", points)
+	}
+
+	point := points[0]
+	if point.Index != 5 || point.Period != "2015-01-01" {
+		t.Errorf("expected change at index 5 (2015-01-01), got %d (%s)", point.Index, point.Period)
+	}
+	if math.Abs(point.MeanBefore-10.2) > 1e-9 || point.MeanAfter != 25 {
+		t.Errorf("expected means 10.2 and 25, got %v and %v", point.MeanBefore, point.MeanAfter)
+	}
+	if point.Magnitude != point.MeanAfter-point.MeanBefore || point.RelativeJump != point.Magnitude/point.MeanBefore {
+		t.Errorf("inconsistent magnitude %v and relative jump %v", point.Magnitude, point.RelativeJump)
+	}
+}
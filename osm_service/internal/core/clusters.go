@@ -0,0 +1,83 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"osm_service/internal/domain/model"
+)
+
+// MinClusterSize — наименьший размер кластера в км. Меньшие кластеры не содержат
+// объектов, а число кластеров области растет квадратично.
+const MinClusterSize = 0.1
+
+// MaxClusters ограничивает число кластеров датасета и бэктеста
+const MaxClusters = 100000
+
+// GenerateClusters splits the bounds into a grid of clusters of roughly clusterSize km per side
+func GenerateClusters(bounds model.Bounds, clusterSize float64) []model.Bounds {
+	minLat, minLon, maxLat, maxLon := bounds.MinLat, bounds.MinLon, bounds.MaxLat, bounds.MaxLon
+	latDiff := maxLat - minLat
+	lonDiff := maxLon - minLon
+	clustersLat, clustersLon := clusterGrid(bounds, clusterSize)
+
+	// Generate clusters
+	var clusters []model.Bounds
+	latStep := latDiff / float64(clustersLat)
+	lonStep := lonDiff / float64(clustersLon)
+
+	for i := 0; i < clustersLat; i++ {
+		for j := 0; j < clustersLon; j++ {
+			cluster := model.Bounds{
+				MinLat: minLat + float64(i)*latStep,
+				MinLon: minLon + float64(j)*lonStep,
+				MaxLat: minLat + float64(i+1)*latStep,
+				MaxLon: minLon + float64(j+1)*lonStep,
+			}
+			clusters = append(clusters, cluster)
+		}
+	}
+
+	return clusters
+}
+
+// ClusterCount returns the number of clusters GenerateClusters would produce, without building them.
+// The grid is counted in float64, so a huge grid saturates at math.MaxInt instead of overflowing.
+func ClusterCount(bounds model.Bounds, clusterSize float64) int {
+	clustersLat, clustersLon := clusterGridSize(bounds, clusterSize)
+	count := clustersLat * clustersLon
+	if !(count < math.MaxInt32) {
+		return math.MaxInt
+	}
+	return int(count)
+}
+
+// ValidateClusterSize проверяет, что размер кластера не меньше MinClusterSize и делит
+// область не больше чем на maxClusters кластеров
+func ValidateClusterSize(bounds model.Bounds, clusterSize float64, maxClusters int) error {
+	if !(clusterSize >= MinClusterSize) || math.IsInf(clusterSize, 1) {
+		return fmt.Errorf("must be a finite size of at least %g km, got %g", MinClusterSize, clusterSize)
+	}
+	if count := ClusterCount(bounds, clusterSize); count > maxClusters {
+		if count == math.MaxInt {
+			return fmt.Errorf("splits the area into more than %d clusters", maxClusters)
+		}
+		return fmt.Errorf("splits the area into %d clusters, at most %d allowed", count, maxClusters)
+	}
+	return nil
+}
+
+// clusterGrid returns the number of clusters in each direction
+func clusterGrid(bounds model.Bounds, clusterSize float64) (int, int) {
+	clustersLat, clustersLon := clusterGridSize(bounds, clusterSize)
+	return int(clustersLat), int(clustersLon)
+}
+
+// clusterGridSize returns the number of clusters in each direction as float64,
+// so that it can be checked before converting to int
+func clusterGridSize(bounds model.Bounds, clusterSize float64) (float64, float64) {
+	// Approximate conversion from degrees to kilometers (at equator)
+	latKm := (bounds.MaxLat - bounds.MinLat) * 111.32
+	lonKm := (bounds.MaxLon - bounds.MinLon) * 111.32 * math.Cos(bounds.MinLat*math.Pi/180)
+
+	return math.Ceil(latKm / clusterSize), math.Ceil(lonKm / clusterSize)
+}
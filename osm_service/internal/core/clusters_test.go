@@ -0,0 +1,55 @@
+package core
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"osm_service/internal/domain/model"
+)
+
+func TestClusterCount(t *testing.T) {
+	moscow := model.Bounds{MinLat: 55.5, MinLon: 37.3, MaxLat: 56.0, MaxLon: 37.9}
+
+	if got, want := ClusterCount(moscow, 1), len(GenerateClusters(moscow, 1)); got != want {
+		t.Errorf("ClusterCount = %d, GenerateClusters made %d clusters", got, want)
+	}
+	// Без проверки в float64 такая сетка переполняла int и давала отрицательное число
+	if got := ClusterCount(moscow, 1e-9); got != math.MaxInt {
+		t.Errorf("ClusterCount for a tiny cluster size = %d, want saturated math.MaxInt", got)
+	}
+}
+
+func TestValidateClusterSize(t *testing.T) {
+	moscow := model.Bounds{MinLat: 55.5, MinLon: 37.3, MaxLat: 56.0, MaxLon: 37.9}
+
+	tests := []struct {
+		name        string
+		clusterSize float64
+		maxClusters int
+		wantErr     string
+	}{
+		{"valid", 2, MaxBatchAreas, ""},
+		{"zero", 0, MaxBatchAreas, "at least"},
+		{"negative", -1, MaxBatchAreas, "at least"},
+		{"below minimum", 1e-9, MaxClusters, "at least"},
+		{"not a number", math.NaN(), MaxClusters, "at least"},
+		{"infinite", math.Inf(1), MaxClusters, "finite"},
+		{"too many clusters", 0.5, MaxBatchAreas, "at most 1000 allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateClusterSize(moscow, tt.clusterSize, tt.maxClusters)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateClusterSize = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateClusterSize = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
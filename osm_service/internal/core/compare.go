@@ -0,0 +1,70 @@
+package core
+
+import (
+	"context"
+	"osm_service/internal/domain/model"
+	"sort"
+)
+
+// Пределы числа областей в одном сравнении
+const (
+	MinCompareAreas = 2
+	MaxCompareAreas = 20
+)
+
+// CompareAreas считает для каждой области признаки и предсказание и ранжирует области
+// по предсказанному уровню активности. В areas заданы ID и BBox областей; ошибка
+// области сохраняется в ее Err и не прерывает сравнение остальных. Области с прогнозом
+// ряда вместо модели отмечаются Fallback и не ранжируются.
+func (s *PredictionService) CompareAreas(
+	ctx context.Context,
+	areas []model.ComparedArea,
+	shopType string,
+	predictionYear string,
+	trainPeriod string,
+) []model.ComparedArea {
+	compared := make([]model.ComparedArea, len(areas))
+	s.forEachArea(ctx, len(areas), func(i int) {
+		area := model.ComparedArea{ID: areas[i].ID, BBox: areas[i].BBox}
+		prediction, features, err := s.getPrediction(ctx, area.BBox, shopType, predictionYear, trainPeriod, false)
+		if features != nil {
+			area.Spatial = &features.Spatial
+			area.Temporal = &features.Temporal
+		}
+		area.Prediction = prediction
+		area.Err = err
+		compared[i] = area
+	})
+	if err := ctx.Err(); err != nil {
+		for i := range compared {
+			if compared[i].Prediction == nil && compared[i].Err == nil {
+				compared[i] = model.ComparedArea{ID: areas[i].ID, BBox: areas[i].BBox, Err: err}
+			}
+		}
+	}
+
+	rankAreas(compared)
+	return compared
+}
+
+// rankAreas нумерует области с предсказанием модели по убыванию уровня активности.
+// Уровень активности прогноза ряда считается в другой шкале, такие области
+// отмечаются Fallback и остаются без места.
+func rankAreas(areas []model.ComparedArea) {
+	var ranked []int
+	for i, area := range areas {
+		switch {
+		case area.Prediction == nil:
+		case area.Prediction.Fallback:
+			areas[i].Fallback = true
+		default:
+			ranked = append(ranked, i)
+		}
+	}
+	sort.SliceStable(ranked, func(a, b int) bool {
+		return areas[ranked[a]].Prediction.ActivityLevel > areas[ranked[b]].Prediction.ActivityLevel
+	})
+	for rank, i := range ranked {
+		areas[i].Rank = rank + 1
+	}
+}
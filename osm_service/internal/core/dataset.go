@@ -0,0 +1,272 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"osm_service/internal/domain/model"
+	"osm_service/internal/domain/repository"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DatasetProgress получает события построения датасета. При параллельной обработке
+// методы вызываются из нескольких горутин.
+type DatasetProgress interface {
+	SetTotal(clusters int)
+	ClusterDone(index int, bbox string, err error)
+	Warning(message string)
+	OverpassQuery(duration time.Duration, err error)
+}
+
+// ErrCheckpointsDisabled возвращается при продолжении построения без хранилища чекпоинтов
+var ErrCheckpointsDisabled = fmt.Errorf("dataset checkpoints are %w", ErrNotConfigured)
+
+// SetDatasetCheckpoints включает сохранение готовых кластеров во время построения датасета,
+// чтобы прерванное построение можно было продолжить
+func (s *PredictionService) SetDatasetCheckpoints(checkpoints repository.DatasetCheckpoints) {
+	s.datasetCheckpoints = checkpoints
+}
+
+// DatasetCheckpointRequest возвращает параметры построения, сохраненные для задачи jobID
+func (s *PredictionService) DatasetCheckpointRequest(ctx context.Context, jobID string) (model.DatasetRequest, error) {
+	if s.datasetCheckpoints == nil {
+		return model.DatasetRequest{}, ErrCheckpointsDisabled
+	}
+	return s.datasetCheckpoints.LoadRequest(ctx, jobID)
+}
+
+// RemoveDatasetCheckpoint удаляет чекпоинт задачи jobID, например отмененной пользователем.
+// Без хранилища чекпоинтов ничего не делает.
+func (s *PredictionService) RemoveDatasetCheckpoint(ctx context.Context, jobID string) error {
+	if s.datasetCheckpoints == nil {
+		return nil
+	}
+	return s.datasetCheckpoints.Remove(ctx, jobID)
+}
+
+// clusterBuilder считает данные одного кластера датасета
+type clusterBuilder func(ctx context.Context, index int, cluster model.Bounds) (model.DatasetCluster, error)
+
+// SetDatasetConcurrency задает число кластеров, обрабатываемых одновременно при построении
+// датасета. Значение ограничивается числом слотов Overpass: каждый кластер делает запросы
+// к Overpass последовательно, и лишние воркеры только ждали бы свободного слота.
+// 0 — по числу слотов.
+func (s *PredictionService) SetDatasetConcurrency(workers int) {
+	s.datasetConcurrency = workers
+}
+
+// datasetWorkers возвращает фактическое число воркеров построения датасета
+func (s *PredictionService) datasetWorkers() int {
+	return s.overpassWorkers(s.datasetConcurrency)
+}
+
+// overpassWorkers ограничивает число воркеров, которые обращаются к Overpass,
+// числом его слотов; configured <= 0 — по числу слотов
+func (s *PredictionService) overpassWorkers(configured int) int {
+	slots := s.overpassRepo.Slots()
+	if slots < 1 {
+		slots = 1
+	}
+	if configured <= 0 || configured > slots {
+		return slots
+	}
+	return configured
+}
+
+// DatasetFilename возвращает имя файла датасета, которое ожидает ML сервис
+func DatasetFilename(req model.DatasetRequest) string {
+	return fmt.Sprintf("dataset_%s_%s_to_%s.json",
+		req.ShopType,
+		req.StartDate.Format("20060102"),
+		req.EndDate.Format("20060102"))
+}
+
+// BuildDataset строит датасет по кластерам области и сохраняет его в dir.
+// Ошибка отдельного кластера не прерывает построение: кластер остается без данных,
+// а ошибка передается в progress. Если включены чекпоинты, готовые кластеры сохраняются
+// под jobID, и повторный вызов с тем же jobID считает только недостающие. При отмене
+// ctx чекпоинт удаляется.
+// Возвращает путь к файлу датасета.
+func (s *PredictionService) BuildDataset(ctx context.Context, jobID string, req model.DatasetRequest, dir string, progress DatasetProgress) (string, error) {
+	bounds, err := parseBounds(req.BBox)
+	if err != nil {
+		return "", err
+	}
+	clusters := GenerateClusters(bounds, req.ClusterSize)
+	progress.SetTotal(len(clusters))
+	ctx = repository.WithQueryObserver(ctx, progress.OverpassQuery)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create datasets directory: %w", err)
+	}
+
+	completed, err := s.loadCheckpoint(ctx, jobID, req)
+	if err != nil {
+		return "", err
+	}
+	if len(completed) > 0 {
+		log.Printf("Resuming dataset job %s: %d of %d clusters already done", jobID, len(completed), len(clusters))
+	}
+
+	builder := func(ctx context.Context, index int, cluster model.Bounds) (model.DatasetCluster, error) {
+		result, err := s.BuildCluster(ctx, req, index, cluster)
+		if err == nil && s.datasetCheckpoints != nil {
+			if err := s.datasetCheckpoints.SaveCluster(ctx, jobID, result); err != nil {
+				log.Printf("Warning: failed to checkpoint cluster %d: %v", index, err)
+				progress.Warning(fmt.Sprintf("failed to checkpoint cluster %d: %v", index, err))
+			}
+		}
+		return result, err
+	}
+	dataset, failed := processClusters(ctx, clusters, completed, s.datasetWorkers(), builder, progress)
+	if ctx.Err() != nil {
+		// Контекст отменяется только при отмене задачи, продолжать ее не будут.
+		// Воркеры уже остановлены, поэтому новые кластеры в чекпоинт не запишутся.
+		if err := s.RemoveDatasetCheckpoint(context.WithoutCancel(ctx), jobID); err != nil {
+			log.Printf("Warning: failed to remove checkpoint of cancelled job %s: %v", jobID, err)
+		}
+		return "", ctx.Err()
+	}
+	if failed > 0 {
+		log.Printf("Warning: %d of %d clusters failed, they are saved without data", failed, len(clusters))
+		progress.Warning(fmt.Sprintf("%d of %d clusters failed, they are saved without data", failed, len(clusters)))
+	}
+
+	path := filepath.Join(dir, DatasetFilename(req))
+	log.Printf("Saving dataset to: %s", path)
+
+	// Файл пишется атомарно: читатели датасета не увидят его недописанным
+	err = repository.WriteFileAtomic(path, func(file *os.File) error {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(dataset)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to write dataset: %w", err)
+	}
+
+	log.Printf("Dataset successfully saved to: %s", path)
+
+	// Кластеры с ошибкой не сохраняются в чекпоинт, поэтому при частичном
+	// результате чекпоинт остается, и построение можно повторить для них
+	if s.datasetCheckpoints != nil && failed == 0 {
+		if err := s.datasetCheckpoints.Remove(ctx, jobID); err != nil {
+			log.Printf("Warning: failed to remove checkpoint of job %s: %v", jobID, err)
+		}
+	}
+	return path, nil
+}
+
+// loadCheckpoint сохраняет параметры построения для задачи и возвращает уже готовые кластеры
+func (s *PredictionService) loadCheckpoint(ctx context.Context, jobID string, req model.DatasetRequest) (map[int]model.DatasetCluster, error) {
+	if s.datasetCheckpoints == nil {
+		return nil, nil
+	}
+	if err := s.datasetCheckpoints.SaveRequest(ctx, jobID, req); err != nil {
+		return nil, fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return s.datasetCheckpoints.LoadClusters(ctx, jobID)
+}
+
+// processClusters обрабатывает кластеры пулом из workers горутин. Результат кластера
+// записывается по его индексу, поэтому порядок в датасете не зависит от порядка
+// завершения. Кластеры из completed берутся готовыми. Возвращает датасет и число
+// кластеров с ошибкой.
+func processClusters(
+	ctx context.Context,
+	clusters []model.Bounds,
+	completed map[int]model.DatasetCluster,
+	workers int,
+	build clusterBuilder,
+	progress DatasetProgress,
+) (model.Dataset, int) {
+	dataset := model.Dataset{
+		Clusters: make([]model.DatasetCluster, len(clusters)),
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	indexes := make(chan int)
+	var failed atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				log.Printf("Processing cluster %d/%d", i+1, len(clusters))
+
+				result, err := build(ctx, i, clusters[i])
+				if ctx.Err() != nil {
+					continue
+				}
+				if err != nil {
+					log.Printf("Warning: %v", err)
+					failed.Add(1)
+				}
+				dataset.Clusters[i] = result
+				progress.ClusterDone(i, result.Bbox, err)
+			}
+		}()
+	}
+
+	for i := range clusters {
+		if cluster, ok := completed[i]; ok {
+			dataset.Clusters[i] = cluster
+			progress.ClusterDone(i, cluster.Bbox, nil)
+			continue
+		}
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	return dataset, int(failed.Load())
+}
+
+// BuildCluster считает признаки кластера по годам. При ошибке возвращается
+// кластер без данных, чтобы индексы в датасете не сдвигались.
+func (s *PredictionService) BuildCluster(ctx context.Context, req model.DatasetRequest, index int, cluster model.Bounds) (model.DatasetCluster, error) {
+	result := model.DatasetCluster{
+		Index: index,
+		Bbox:  fmt.Sprintf("%f,%f,%f,%f", cluster.MinLat, cluster.MinLon, cluster.MaxLat, cluster.MaxLon),
+	}
+
+	// Get historical data for the cluster
+	historical, err := s.GetHistoricalDataSampled(ctx, cluster, req.StartDate, req.EndDate, req.ShopType, req.SamplingMonths)
+	if err != nil {
+		return result, fmt.Errorf("failed to get historical data for cluster %d: %w", index, err)
+	}
+
+	// Get current data for the cluster
+	current, err := s.GetCurrentData(ctx, cluster, req.ShopType)
+	if err != nil {
+		return result, fmt.Errorf("failed to get current data for cluster %d: %w", index, err)
+	}
+
+	// Calculate features with cluster bounds
+	features := s.CalculateFeaturesForPeriod(ctx, current, historical, req.StartDate, req.EndDate, cluster)
+
+	// Create yearly data
+	result.Data = make([]model.DatasetYear, 0)
+	for _, yearFeatures := range YearlyFeatures(features, historical, req.StartDate, req.EndDate) {
+		result.Data = append(result.Data, model.DatasetYear{
+			Year: yearFeatures.Year,
+			Data: []model.FeatureVector{yearFeatures.Features},
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"osm_service/internal/domain/model"
+)
+
+func TestMain(m *testing.M) {
+	// processClusters пишет в лог строку на каждый кластер
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// recordingProgress запоминает завершенные кластеры
+type recordingProgress struct {
+	mu     sync.Mutex
+	done   map[int]string
+	failed map[int]error
+}
+
+func newRecordingProgress() *recordingProgress {
+	return &recordingProgress{done: make(map[int]string), failed: make(map[int]error)}
+}
+
+func (p *recordingProgress) SetTotal(clusters int) {}
+
+func (p *recordingProgress) ClusterDone(index int, bbox string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done[index] = bbox
+	if err != nil {
+		p.failed[index] = err
+	}
+}
+
+func (p *recordingProgress) Warning(message string) {}
+
+func (p *recordingProgress) OverpassQuery(duration time.Duration, err error) {}
+
+func testClusters(n int) []model.Bounds {
+	clusters := make([]model.Bounds, n)
+	for i := range clusters {
+		lat := 55.0 + float64(i)*0.01
+		clusters[i] = model.Bounds{MinLat: lat, MinLon: 37.0, MaxLat: lat + 0.01, MaxLon: 37.01}
+	}
+	return clusters
+}
+
+// fakeBuilder строит кластер с задержкой latency, кластеры из failing завершаются ошибкой.
+// Задержка убывает с индексом, чтобы кластеры завершались не по порядку.
+func fakeBuilder(latency time.Duration, reverse bool, failing map[int]bool) clusterBuilder {
+	return func(ctx context.Context, index int, cluster model.Bounds) (model.DatasetCluster, error) {
+		delay := latency
+		if reverse {
+			delay = latency / time.Duration(index+1)
+		}
+		time.Sleep(delay)
+
+		result := model.DatasetCluster{
+			Index: index,
+			Bbox:  fmt.Sprintf("%f,%f,%f,%f", cluster.MinLat, cluster.MinLon, cluster.MaxLat, cluster.MaxLon),
+		}
+		if failing[index] {
+			return result, fmt.Errorf("cluster %d: overpass unavailable", index)
+		}
+		result.Data = []model.DatasetYear{{Year: 2023, Data: []model.FeatureVector{{TotalObjects: index}}}}
+		return result, nil
+	}
+}
+
+func TestProcessClustersDeterministicOrder(t *testing.T) {
+	clusters := testClusters(20)
+	failing := map[int]bool{3: true, 11: true, 17: true}
+	completed := map[int]model.DatasetCluster{
+		5: {Index: 5, Bbox: "resumed"},
+	}
+
+	for _, workers := range []int{1, 4, 20} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			progress := newRecordingProgress()
+			dataset, failed := processClusters(context.Background(), clusters, completed, workers,
+				fakeBuilder(5*time.Millisecond, true, failing), progress)
+
+			if failed != len(failing) {
+				t.Errorf("failed = %d, want %d", failed, len(failing))
+			}
+			if len(dataset.Clusters) != len(clusters) {
+				t.Fatalf("got %d clusters, want %d", len(dataset.Clusters), len(clusters))
+			}
+			for i, cluster := range dataset.Clusters {
+				if cluster.Index != i {
+					t.Errorf("clusters[%d].Index = %d", i, cluster.Index)
+				}
+				switch {
+				case i == 5:
+					if cluster.Bbox != "resumed" {
+						t.Errorf("completed cluster 5 was rebuilt: %+v", cluster)
+					}
+				case failing[i]:
+					if len(cluster.Data) != 0 {
+						t.Errorf("failed cluster %d has data", i)
+					}
+				default:
+					if len(cluster.Data) != 1 || cluster.Data[0].Data[0].TotalObjects != i {
+						t.Errorf("clusters[%d] holds data of another cluster: %+v", i, cluster.Data)
+					}
+				}
+			}
+
+			if len(progress.done) != len(clusters) {
+				t.Errorf("progress reported %d clusters, want %d", len(progress.done), len(clusters))
+			}
+			if len(progress.failed) != len(failing) {
+				t.Errorf("progress reported %d failures, want %d", len(progress.failed), len(failing))
+			}
+			for i := range failing {
+				if progress.failed[i] == nil {
+					t.Errorf("progress has no error for cluster %d", i)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessClustersCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var once sync.Once
+	build := func(ctx context.Context, index int, cluster model.Bounds) (model.DatasetCluster, error) {
+		once.Do(cancel)
+		return model.DatasetCluster{Index: index}, ctx.Err()
+	}
+
+	progress := newRecordingProgress()
+	_, failed := processClusters(ctx, testClusters(50), nil, 2, build, progress)
+	if failed != 0 {
+		t.Errorf("failed = %d, cancelled clusters must not count as failures", failed)
+	}
+	if len(progress.done) != 0 {
+		t.Errorf("progress reported %d clusters after cancellation", len(progress.done))
+	}
+}
+
+func BenchmarkProcessClusters(b *testing.B) {
+	clusters := testClusters(64)
+	build := fakeBuilder(2*time.Millisecond, false, nil)
+
+	// Кластеры ждут Overpass, а не процессор, поэтому число воркеров не связано с GOMAXPROCS
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				processClusters(context.Background(), clusters, nil, workers, build, newRecordingProgress())
+			}
+		})
+	}
+}
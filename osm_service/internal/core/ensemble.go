@@ -0,0 +1,227 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"osm_service/internal/domain/model"
+	"time"
+)
+
+// ensembleModelName — значение ModelUsed для ансамблевого предсказания
+const ensembleModelName = "ensemble"
+
+// ensemblePeriod — история и признаки области для одного периода обучения
+type ensemblePeriod struct {
+	historical []model.HistoricalData
+	features   model.FeatureVector
+	startDate  time.Time
+	endDate    time.Time
+	err        error
+}
+
+// EnsemblePrediction опрашивает все активные модели категории из реестра, пересекающиеся
+// с областью, и усредняет их предсказания с весами overlap × 1/MSE, где overlap — доля
+// области, покрытая моделью (см. metricWeights для моделей без метрик). Признаки
+// считаются один раз на каждый период обучения и шаг снимков.
+func (s *PredictionService) EnsemblePrediction(ctx context.Context, bbox string, shopType string, predictionYear string) (*model.Prediction, error) {
+	if s.modelRegistry == nil {
+		return nil, ErrRegistryDisabled
+	}
+
+	bounds, err := parseBounds(bbox)
+	if err != nil {
+		return nil, err
+	}
+
+	registered, err := s.modelRegistry.ListModels(ctx, model.ModelFilter{Category: shopType, Status: model.ModelStatusActive})
+	if err != nil {
+		return nil, err
+	}
+
+	// Модели, покрывающие область, и доля покрытия
+	var candidates []model.RegisteredModel
+	var overlaps []float64
+	var metrics []model.ModelMetrics
+	for _, candidate := range registered {
+		coverage, err := parseBounds(candidate.Coverage)
+		if err != nil {
+			log.Printf("Warning: skipping model %s with invalid coverage: %v", candidate.Name, err)
+			continue
+		}
+		if overlap := overlapShare(bounds, coverage); overlap > 0 {
+			candidates = append(candidates, candidate)
+			overlaps = append(overlaps, overlap)
+			metrics = append(metrics, candidate.Metrics)
+		}
+	}
+	metricWeight := metricWeights(metrics)
+
+	periods := make(map[string]*ensemblePeriod)
+	combined := &model.Prediction{ModelUsed: ensembleModelName}
+	var members []model.EnsembleMember
+	var rawWeights []float64
+	var totalWeight float64
+	var best *ensemblePeriod
+	var bestWeight float64
+	var firstErr error
+	var predictedNew, predictedClosed, totalObjects float64
+
+	for i, candidate := range candidates {
+		period := candidate.TrainPeriod()
+		key := fmt.Sprintf("%s/%d", period, candidate.SamplingStep())
+		data, ok := periods[key]
+		if !ok {
+			data = s.ensemblePeriod(ctx, bounds, shopType, candidate.TrainStart, candidate.TrainEnd, candidate.SamplingStep())
+			periods[key] = data
+		}
+
+		member := model.EnsembleMember{
+			Model:        candidate.Name,
+			TrainPeriod:  period,
+			Overlap:      overlaps[i],
+			MetricWeight: metricWeight[i],
+		}
+
+		var prediction *model.Prediction
+		err := data.err
+		if err == nil {
+			prediction, err = s.predict(ctx, model.PredictionQuery{
+				BBox:           bbox,
+				ShopType:       shopType,
+				PredictionYear: predictionYear,
+				TrainPeriod:    period,
+				Features:       &data.features,
+				Model:          candidate.Name,
+			}, bounds)
+		}
+		if err != nil {
+			log.Printf("Warning: ensemble model %s failed: %v", candidate.Name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			member.Error = err.Error()
+			members = append(members, member)
+			rawWeights = append(rawWeights, 0)
+			continue
+		}
+
+		weight := member.Overlap * member.MetricWeight
+		member.ActivityLevel = prediction.ActivityLevel
+		member.PredictedNew = prediction.PredictedNew
+		member.PredictedClosed = prediction.PredictedClosed
+		members = append(members, member)
+		rawWeights = append(rawWeights, weight)
+		totalWeight += weight
+
+		combined.ActivityLevel += weight * prediction.ActivityLevel
+		combined.Trend += weight * prediction.Trend
+		predictedNew += weight * float64(prediction.PredictedNew)
+		predictedClosed += weight * float64(prediction.PredictedClosed)
+		totalObjects += weight * float64(prediction.TotalObjects)
+
+		if weight > bestWeight {
+			best, bestWeight = data, weight
+		}
+	}
+
+	if len(members) == 0 {
+		return nil, fmt.Errorf("%w for category %s in bbox %s", model.ErrModelNotFound, shopType, bbox)
+	}
+	if totalWeight == 0 {
+		return nil, fmt.Errorf("no model in ensemble returned a prediction: %w", firstErr)
+	}
+
+	for i := range members {
+		members[i].Weight = rawWeights[i] / totalWeight
+	}
+	combined.ActivityLevel /= totalWeight
+	combined.Trend /= totalWeight
+	combined.PredictedNew = int(math.Round(predictedNew / totalWeight))
+	combined.PredictedClosed = int(math.Round(predictedClosed / totalWeight))
+	combined.TotalObjects = int(math.Round(totalObjects / totalWeight))
+	combined.Ensemble = members
+
+	addUncertainty(combined, best.historical, &best.features, best.startDate, best.endDate, false)
+	return combined, nil
+}
+
+// ensemblePeriod собирает историю и признаки области для периода обучения модели
+// с шагом снимков ее датасета
+func (s *PredictionService) ensemblePeriod(
+	ctx context.Context,
+	bounds model.Bounds,
+	shopType string,
+	startDate time.Time,
+	endDate time.Time,
+	stepMonths int,
+) *ensemblePeriod {
+	data := &ensemblePeriod{startDate: startDate, endDate: endDate}
+
+	data.historical, data.err = s.GetHistoricalDataSampled(ctx, bounds, startDate, endDate, shopType, stepMonths)
+	if data.err != nil {
+		data.err = fmt.Errorf("failed to get historical data: %w", data.err)
+		return data
+	}
+
+	data.features, data.err = s.predictionFeatures(ctx, bounds, data.historical, startDate, endDate, shopType)
+	return data
+}
+
+// metricWeights возвращает обратные ошибки валидации моделей (1/MSE). Модели без метрик
+// получают медианный вес моделей с метриками, чтобы их вес был сопоставим с остальными;
+// если метрик нет ни у одной модели, веса всех моделей равны 1.
+func metricWeights(metrics []model.ModelMetrics) []float64 {
+	weights := make([]float64, len(metrics))
+	var known []float64
+	for i, m := range metrics {
+		switch {
+		case m.MSE > 0:
+			weights[i] = 1 / m.MSE
+		case m.RMSE > 0:
+			weights[i] = 1 / (m.RMSE * m.RMSE)
+		default:
+			continue
+		}
+		known = append(known, weights[i])
+	}
+
+	fallback := 1.0
+	if len(known) > 0 {
+		fallback = median(known)
+	}
+	for i := range weights {
+		if weights[i] == 0 {
+			weights[i] = fallback
+		}
+	}
+	return weights
+}
+
+// covers сообщает, что coverage целиком содержит area. Координаты покрытия в реестре
+// хранятся с точностью до 6 знаков, поэтому границы сравниваются с таким допуском.
+func covers(coverage, area model.Bounds) bool {
+	const eps = 1e-6
+	return coverage.MinLat <= area.MinLat+eps && coverage.MinLon <= area.MinLon+eps &&
+		coverage.MaxLat >= area.MaxLat-eps && coverage.MaxLon >= area.MaxLon-eps
+}
+
+// overlapShare возвращает долю площади area, покрытую coverage
+func overlapShare(area, coverage model.Bounds) float64 {
+	intersection := model.Bounds{
+		MinLat: math.Max(area.MinLat, coverage.MinLat),
+		MinLon: math.Max(area.MinLon, coverage.MinLon),
+		MaxLat: math.Min(area.MaxLat, coverage.MaxLat),
+		MaxLon: math.Min(area.MaxLon, coverage.MaxLon),
+	}
+	if intersection.MinLat >= intersection.MaxLat || intersection.MinLon >= intersection.MaxLon {
+		return 0
+	}
+
+	total := calculateArea(area)
+	if total == 0 {
+		return 0
+	}
+	return calculateArea(intersection) / total
+}
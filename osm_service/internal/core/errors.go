@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"osm_service/internal/domain/model"
+	"osm_service/internal/domain/repository"
+	"strings"
+)
+
+// Категории ошибок сервиса. Конкретные ошибки оборачивают одну из них,
+// а API по категории выбирает HTTP статус ответа.
+var (
+	ErrValidation    = errors.New("invalid request")
+	ErrNotFound      = errors.New("not found")
+	ErrConflict      = errors.New("conflict")
+	ErrUnavailable   = errors.New("upstream service is unavailable")
+	ErrTimeout       = errors.New("upstream service timed out")
+	ErrNotConfigured = errors.New("not configured")
+)
+
+// FieldError описывает некорректное поле запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError перечисляет все некорректные поля запроса
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Add добавляет ошибку поля
+func (e *ValidationError) Add(field string, format string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err возвращает e, если есть ошибки полей, иначе nil
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		parts[i] = field.Field + ": " + field.Message
+	}
+	return "invalid request: " + strings.Join(parts, "; ")
+}
+
+// Unwrap относит ошибку к категории ErrValidation
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// invalidField возвращает ошибку проверки одного поля
+func invalidField(field string, format string, args ...any) error {
+	var err ValidationError
+	err.Add(field, format, args...)
+	return &err
+}
+
+// ErrorKind возвращает категорию ошибки (ErrValidation, ErrNotFound, ErrConflict,
+// ErrUnavailable, ErrTimeout, ErrNotConfigured) или nil, если ошибка внутренняя. Учитываются и ошибки
+// нижних слоев: отсутствие модели, недоступность ML сервиса и Overpass, дедлайн контекста.
+func ErrorKind(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrValidation), errors.Is(err, model.ErrInvalidBBox):
+		return ErrValidation
+	case errors.Is(err, ErrNotFound),
+		errors.Is(err, model.ErrModelNotFound),
+		errors.Is(err, model.ErrCheckpointNotFound):
+		return ErrNotFound
+	case errors.Is(err, ErrConflict), errors.Is(err, model.ErrModelExists):
+		return ErrConflict
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout
+	case errors.Is(err, ErrUnavailable),
+		errors.Is(err, model.ErrMLServiceUnavailable),
+		errors.Is(err, repository.ErrOverpassUnavailable):
+		return ErrUnavailable
+	case errors.Is(err, ErrNotConfigured):
+		return ErrNotConfigured
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"fmt"
+	"osm_service/internal/domain/model"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// YearlyFeatures строит признаки кластера по годам периода в формате датасета.
+// Этот же расчет используется при предсказании, чтобы признаки обучения и инференса совпадали.
+func YearlyFeatures(features model.FeatureSet, historical []model.HistoricalData, startDate, endDate time.Time) []model.YearFeatures {
+	currentYear := startDate.Year()
+	endYear := endDate.Year()
+
+	// Create map for yearly historical data: the last snapshot of a year
+	// gives the total, openings and closures are summed over the year
+	yearlyHistoricalData := make(map[string]model.HistoricalData)
+	for _, data := range historical {
+		year := data.Period[:4]
+		if prev, ok := yearlyHistoricalData[year]; ok {
+			data.NewObjects += prev.NewObjects
+			data.ClosedObjects += prev.ClosedObjects
+		}
+		yearlyHistoricalData[year] = data
+	}
+
+	// Sort years for correct trend calculation
+	var years []string
+	for year := currentYear; year <= endYear; year++ {
+		years = append(years, fmt.Sprintf("%d", year))
+	}
+	sort.Strings(years)
+
+	yearlyData := make([]model.YearFeatures, 0, len(years))
+	for _, yearStr := range years {
+		yearData, exists := yearlyHistoricalData[yearStr]
+
+		// If no data for specific year, use data from last available year
+		if !exists {
+			var lastYear string
+			for y := range yearlyHistoricalData {
+				if y <= yearStr && (lastYear == "" || y > lastYear) {
+					lastYear = y
+				}
+			}
+			if lastYear != "" {
+				yearData = yearlyHistoricalData[lastYear]
+			}
+		}
+
+		// Calculate metrics for current year
+		totalObjects := yearData.TotalObjects
+		newObjects := yearData.NewObjects
+		closedObjects := yearData.ClosedObjects
+
+		// Calculate growth and closure rates
+		var newObjectRate, closureRate float64
+		if totalObjects > 0 {
+			newObjectRate = float64(newObjects) / float64(totalObjects)
+			closureRate = float64(closedObjects) / float64(totalObjects)
+		}
+
+		// Calculate object density (empty clusters have no area and would give +Inf)
+		var objectDensity float64
+		if features.Spatial.AvgArea > 0 {
+			objectDensity = float64(totalObjects) / features.Spatial.AvgArea
+		}
+
+		yearInt, _ := strconv.Atoi(yearStr)
+		yearlyData = append(yearlyData, model.YearFeatures{
+			Year: yearInt,
+			Features: model.FeatureVector{
+				AvgArea:          features.Spatial.AvgArea,
+				AvgDistToPrimary: features.Spatial.AvgDistToPrimary,
+				AvgDistToSubway:  features.Spatial.AvgDistToSubway,
+				ClosureRate:      closureRate,
+				NewObjectRate:    newObjectRate,
+				ObjectDensity:    objectDensity,
+				TotalObjects:     totalObjects,
+
+				DeseasonalizedTrend: features.Temporal.DeseasonalizedTrendSlope,
+				SeasonalAmplitude:   features.Temporal.SeasonalAmplitude,
+			},
+		})
+	}
+
+	return yearlyData
+}
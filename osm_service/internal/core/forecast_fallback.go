@@ -0,0 +1,160 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"osm_service/internal/domain/model"
+	"osm_service/internal/forecast"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// forecastLevel — доверительный уровень интервалов прогноза
+const forecastLevel = 0.95
+
+// forecastModelPrefix — префикс ModelUsed предсказаний, построенных прогнозом ряда
+const forecastModelPrefix = "forecast_"
+
+// SetForecastMethod задает метод прогноза ряда (см. константы пакета forecast)
+func (s *PredictionService) SetForecastMethod(method string) {
+	s.forecastMethod = method
+}
+
+// EnableForecastBenchmark включает расчет прогноза в Go рядом с ответом ML сервиса
+// для сравнения качества
+func (s *PredictionService) EnableForecastBenchmark() {
+	s.forecastBenchmark = true
+}
+
+// forecastPrediction строит предсказание по ряду количества объектов без ML сервиса
+func (s *PredictionService) forecastPrediction(
+	historical []model.HistoricalData,
+	startDate time.Time,
+	endDate time.Time,
+	predictionYear string,
+) (*model.Prediction, error) {
+	year, err := strconv.Atoi(predictionYear)
+	if err != nil {
+		return nil, invalidField("prediction_year", "must be a year, got %q", predictionYear)
+	}
+	horizon := year - endDate.Year()
+	if horizon < 1 {
+		horizon = 1
+	}
+
+	result, err := forecast.Forecast(historical, s.forecastMethod, horizon, forecastLevel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build forecast: %w", err)
+	}
+
+	series := forecast.Series(historical)
+	last := series[len(series)-1]
+	predicted := result.Points[horizon-1].Value
+
+	temporalAnalyzer := TemporalAnalyzer{}
+	temporal := temporalAnalyzer.Analyze(historical, endDate.Year()-startDate.Year())
+
+	prediction := &model.Prediction{
+		ActivityLevel: activityLevel(temporal),
+		TotalObjects:  int(math.Round(math.Max(predicted, 0))),
+		ModelUsed:     forecastModelPrefix + result.Method,
+		Forecast:      &result,
+	}
+	if last > 0 {
+		prediction.Trend = (predicted - last) / last / float64(horizon)
+	}
+	if delta := int(math.Round(predicted - last)); delta > 0 {
+		prediction.PredictedNew = delta
+	} else {
+		prediction.PredictedClosed = -delta
+	}
+
+	return prediction, nil
+}
+
+// benchmarkForecast добавляет прогноз Go к ответу ML сервиса и логирует расхождение
+func (s *PredictionService) benchmarkForecast(
+	prediction *model.Prediction,
+	historical []model.HistoricalData,
+	startDate time.Time,
+	endDate time.Time,
+	predictionYear string,
+) {
+	baseline, err := s.forecastPrediction(historical, startDate, endDate, predictionYear)
+	if err != nil {
+		log.Printf("Warning: forecast benchmark failed: %v", err)
+		return
+	}
+
+	prediction.Forecast = baseline.Forecast
+	log.Printf("Forecast benchmark: ml total_objects=%d, %s total_objects=%d",
+		prediction.TotalObjects, baseline.ModelUsed, baseline.TotalObjects)
+}
+
+// activityLevel повторяет целевую переменную из train_models.py,
+// чтобы прогноз без ML сервиса был в той же шкале
+func activityLevel(temporal model.TemporalFeatures) float64 {
+	normalizedDensity := temporal.ObjectDensity / 10000
+	return temporal.NewObjectRate*0.4 +
+		(1-temporal.ClosureRate)*0.3 +
+		normalizedDensity*0.3
+}
+
+// parseTrainPeriod разбирает период обучения в форматах "2019-2023"
+// и "20190101_to_20231231"
+func parseTrainPeriod(period string) (time.Time, time.Time, error) {
+	if parts := strings.Split(period, "_to_"); len(parts) == 2 {
+		start, err := time.Parse("20060102", parts[0])
+		if err != nil {
+			return time.Time{}, time.Time{}, invalidField("train_period", "invalid start: %v", err)
+		}
+		end, err := time.Parse("20060102", parts[1])
+		if err != nil {
+			return time.Time{}, time.Time{}, invalidField("train_period", "invalid end: %v", err)
+		}
+		return start, end, nil
+	}
+
+	parts := strings.Split(period, "-")
+	if len(parts) != 2 {
+		return time.Time{}, time.Time{}, invalidField("train_period", "expected \"2019-2023\" or \"20190101_to_20231231\", got %q", period)
+	}
+	startYear, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return time.Time{}, time.Time{}, invalidField("train_period", "invalid start: %v", err)
+	}
+	endYear, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return time.Time{}, time.Time{}, invalidField("train_period", "invalid end: %v", err)
+	}
+
+	return time.Date(startYear, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(endYear, 1, 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+// parseBounds разбирает bbox запроса, ошибка относится к полю bbox
+func parseBounds(bbox string) (model.Bounds, error) {
+	bounds, err := model.ParseBounds(bbox)
+	if err != nil {
+		return model.Bounds{}, invalidField("bbox", "%v", err)
+	}
+	return bounds, nil
+}
+
+// canFallBack сообщает, что модель недоступна или не найдена и вместо нее можно
+// построить прогноз ряда. Остальные ошибки (некорректный запрос к ML сервису, его
+// внутренняя ошибка, отмена запроса клиентом) возвращаются вызывающему.
+func canFallBack(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	switch ErrorKind(err) {
+	case ErrUnavailable, ErrTimeout, ErrNotFound:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,230 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"osm_service/internal/domain/model"
+	"time"
+)
+
+// ImpactOptions задает зоны для оценки влияния инфраструктурных событий (в метрах)
+type ImpactOptions struct {
+	// RingRadius — радиус зоны влияния вокруг события
+	RingRadius float64
+	// ControlInner и ControlOuter — внутренний и внешний радиусы контрольного кольца
+	ControlInner float64
+	ControlOuter float64
+}
+
+// withDefaults заполняет незаданные радиусы значениями по умолчанию
+func (o ImpactOptions) withDefaults() ImpactOptions {
+	if o.RingRadius <= 0 {
+		o.RingRadius = 500
+	}
+	if o.ControlInner <= 0 {
+		o.ControlInner = 2 * o.RingRadius
+	}
+	if o.ControlOuter <= o.ControlInner {
+		o.ControlOuter = 2 * o.ControlInner
+	}
+	return o
+}
+
+// AnalyzeImpact находит новые станции метро и главные дороги между датами
+// и оценивает изменение количества объектов вокруг них относительно контрольных зон
+func (s *PredictionService) AnalyzeImpact(
+	ctx context.Context,
+	bounds model.Bounds,
+	startDate time.Time,
+	endDate time.Time,
+	shopType string,
+	opts ImpactOptions,
+) ([]model.ImpactEstimate, error) {
+	opts = opts.withDefaults()
+
+	bbox := fmt.Sprintf("%f,%f,%f,%f", bounds.MinLat, bounds.MinLon, bounds.MaxLat, bounds.MaxLon)
+	startStr := startDate.Format("2006-01-02T15:04:05Z")
+	endStr := endDate.Format("2006-01-02T15:04:05Z")
+
+	events, err := s.detectInfrastructureEvents(ctx, bbox, startStr, endStr)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return []model.ImpactEstimate{}, nil
+	}
+
+	// Объекты запрашиваем с запасом, чтобы контрольные кольца у границ были полными
+	expanded := expandBounds(bounds, opts.ControlOuter)
+	expandedBBox := fmt.Sprintf("%f,%f,%f,%f", expanded.MinLat, expanded.MinLon, expanded.MaxLat, expanded.MaxLon)
+
+	before, err := s.overpassRepo.GetCommercialDataByDate(ctx, expandedBBox, shopType, startStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get start date data: %w", err)
+	}
+	after, err := s.overpassRepo.GetCommercialDataByDate(ctx, expandedBBox, shopType, endStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get end date data: %w", err)
+	}
+	before = taggedElements(before)
+	after = taggedElements(after)
+
+	treatedArea := math.Pi * opts.RingRadius * opts.RingRadius / 1e6
+	controlArea := math.Pi * (opts.ControlOuter*opts.ControlOuter - opts.ControlInner*opts.ControlInner) / 1e6
+
+	estimates := make([]model.ImpactEstimate, 0, len(events))
+	for _, event := range events {
+		estimate := model.ImpactEstimate{Event: event}
+		estimate.TreatedBefore, estimate.ControlBefore = countInZones(before, event, events, opts)
+		estimate.TreatedAfter, estimate.ControlAfter = countInZones(after, event, events, opts)
+
+		estimate.TreatedDensityChange = float64(estimate.TreatedAfter-estimate.TreatedBefore) / treatedArea
+		estimate.ControlDensityChange = float64(estimate.ControlAfter-estimate.ControlBefore) / controlArea
+		estimate.DiffInDiff = estimate.TreatedDensityChange - estimate.ControlDensityChange
+
+		estimate.TreatedGrowth = growth(estimate.TreatedBefore, estimate.TreatedAfter)
+		estimate.ControlGrowth = growth(estimate.ControlBefore, estimate.ControlAfter)
+		estimate.RelativeDiffInDiff = estimate.TreatedGrowth - estimate.ControlGrowth
+
+		estimates = append(estimates, estimate)
+	}
+
+	return estimates, nil
+}
+
+// detectInfrastructureEvents сравнивает станции метро и главные дороги на две даты
+func (s *PredictionService) detectInfrastructureEvents(ctx context.Context, bbox, startStr, endStr string) ([]model.InfrastructureEvent, error) {
+	subwaysBefore, err := s.overpassRepo.GetSubwayData(ctx, bbox, startStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subway data: %w", err)
+	}
+	subwaysAfter, err := s.overpassRepo.GetSubwayData(ctx, bbox, endStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subway data: %w", err)
+	}
+	roadsBefore, err := s.overpassRepo.GetRoadData(ctx, bbox, startStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get road data: %w", err)
+	}
+	roadsAfter, err := s.overpassRepo.GetRoadData(ctx, bbox, endStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get road data: %w", err)
+	}
+
+	var events []model.InfrastructureEvent
+	for _, el := range newElements(subwaysBefore, subwaysAfter) {
+		events = append(events, infrastructureEvent(model.InfrastructureSubwayStation, el))
+	}
+	for _, el := range newRoads(roadsBefore, roadsAfter) {
+		// Для дороги зона влияния строится вокруг центра ее геометрии
+		if el.Tags["highway"] == "primary" {
+			events = append(events, infrastructureEvent(model.InfrastructurePrimaryRoad, el))
+		}
+	}
+
+	return events, nil
+}
+
+// newElements возвращает объекты с тегами, которых не было в первом снимке
+func newElements(before, after []model.OSMElement) []model.OSMElement {
+	existing := make(map[elementKey]struct{}, len(before))
+	for _, el := range before {
+		existing[elementKey{el.Type, el.ID}] = struct{}{}
+	}
+
+	var result []model.OSMElement
+	for _, el := range taggedElements(after) {
+		if _, ok := existing[elementKey{el.Type, el.ID}]; !ok {
+			result = append(result, el)
+		}
+	}
+	return result
+}
+
+// newRoads возвращает линии дорог, которых не было в первом снимке. Дорогу, которую
+// разрезали на части, объединили или перерисовали с новым ID, узнаем по общим узлам:
+// новой считается линия, у которой больше половины узлов не входило в старые дороги
+// (включая дороги других классов, чтобы повышение класса не считалось стройкой).
+func newRoads(before, after []model.OSMElement) []model.OSMElement {
+	existingNodes := make(map[int64]struct{})
+	for _, el := range before {
+		for _, id := range el.NodeIDs {
+			existingNodes[id] = struct{}{}
+		}
+	}
+
+	var result []model.OSMElement
+	for _, el := range newElements(before, after) {
+		shared := 0
+		for _, id := range el.NodeIDs {
+			if _, ok := existingNodes[id]; ok {
+				shared++
+			}
+		}
+		if len(el.NodeIDs) > 0 && 2*shared >= len(el.NodeIDs) {
+			continue
+		}
+		result = append(result, el)
+	}
+	return result
+}
+
+func infrastructureEvent(kind string, el model.OSMElement) model.InfrastructureEvent {
+	return model.InfrastructureEvent{
+		Kind: kind,
+		ID:   el.ID,
+		Name: el.Tags["name"],
+		Lat:  el.Lat,
+		Lon:  el.Lon,
+	}
+}
+
+// countInZones считает объекты в зоне влияния события и в его контрольном кольце.
+// Объекты рядом с другими событиями исключаются из контрольной зоны.
+func countInZones(elements []model.OSMElement, event model.InfrastructureEvent, events []model.InfrastructureEvent, opts ImpactOptions) (treated, control int) {
+	for _, el := range elements {
+		distance := calculateDistance(event.Lat, event.Lon, el.Lat, el.Lon)
+		if distance <= opts.RingRadius {
+			treated++
+			continue
+		}
+		if distance < opts.ControlInner || distance > opts.ControlOuter {
+			continue
+		}
+
+		nearOther := false
+		for _, other := range events {
+			if calculateDistance(other.Lat, other.Lon, el.Lat, el.Lon) <= opts.RingRadius {
+				nearOther = true
+				break
+			}
+		}
+		if !nearOther {
+			control++
+		}
+	}
+	return treated, control
+}
+
+func growth(before, after int) float64 {
+	if before == 0 {
+		return 0
+	}
+	return float64(after-before) / float64(before)
+}
+
+// expandBounds расширяет границы на заданное расстояние в метрах
+func expandBounds(bounds model.Bounds, meters float64) model.Bounds {
+	const metersPerDegree = 111320.0
+	latMid := (bounds.MinLat + bounds.MaxLat) / 2 * math.Pi / 180
+	dLat := meters / metersPerDegree
+	dLon := meters / (metersPerDegree * math.Cos(latMid))
+
+	return model.Bounds{
+		MinLat: math.Max(bounds.MinLat-dLat, -90),
+		MinLon: math.Max(bounds.MinLon-dLon, -180),
+		MaxLat: math.Min(bounds.MaxLat+dLat, 90),
+		MaxLon: math.Min(bounds.MaxLon+dLon, 180),
+	}
+}
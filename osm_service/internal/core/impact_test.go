@@ -0,0 +1,74 @@
+package core
+
+import (
+	"slices"
+	"testing"
+
+	"osm_service/internal/domain/model"
+)
+
+func TestNewRoads(t *testing.T) {
+	primary := map[string]string{"highway": "primary"}
+	secondary := map[string]string{"highway": "secondary"}
+	before := []model.OSMElement{
+		{ID: 10, Type: "way", Tags: primary, NodeIDs: []int64{1, 2, 3, 4, 5}},
+		{ID: 11, Type: "way", Tags: secondary, NodeIDs: []int64{20, 21, 22}},
+	}
+
+	tests := []struct {
+		name  string
+		after []model.OSMElement
+		want  []int64
+	}{
+		{
+			name:  "unchanged",
+			after: before,
+		},
+		{
+			name: "split with new IDs",
+			after: []model.OSMElement{
+				{ID: 12, Type: "way", Tags: primary, NodeIDs: []int64{1, 2, 3}},
+				{ID: 13, Type: "way", Tags: primary, NodeIDs: []int64{3, 4, 5}},
+			},
+		},
+		{
+			name: "extended with a few new nodes",
+			after: []model.OSMElement{
+				{ID: 14, Type: "way", Tags: primary, NodeIDs: []int64{1, 2, 3, 4, 5, 6}},
+			},
+		},
+		{
+			name: "secondary upgraded to primary",
+			after: []model.OSMElement{
+				{ID: 15, Type: "way", Tags: primary, NodeIDs: []int64{20, 21, 22}},
+			},
+		},
+		{
+			name: "new road joining an old one",
+			after: []model.OSMElement{
+				{ID: 10, Type: "way", Tags: primary, NodeIDs: []int64{1, 2, 3, 4, 5}},
+				{ID: 16, Type: "way", Tags: primary, NodeIDs: []int64{5, 30, 31, 32}},
+			},
+			want: []int64{16},
+		},
+		{
+			name: "way without nodes",
+			after: []model.OSMElement{
+				{ID: 17, Type: "way", Tags: primary},
+			},
+			want: []int64{17},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []int64
+			for _, el := range newRoads(before, tt.after) {
+				ids = append(ids, el.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("expected new roads %v, got %v", tt.want, ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"osm_service/internal/domain/model"
+	"osm_service/internal/inference"
+)
+
+// Режимы получения предсказаний
+const (
+	InferenceRemote = "remote" // только ML сервис
+	InferenceLocal  = "local"  // только модели, экспортированные в JSON
+	InferenceBoth   = "both"   // ML сервис с локальной моделью для сравнения и подстраховки
+)
+
+// SetLocalInference подключает экспортированные модели и задает режим предсказаний
+func (s *PredictionService) SetLocalInference(store *inference.Store, mode string) error {
+	switch mode {
+	case InferenceRemote, InferenceLocal, InferenceBoth:
+	default:
+		return fmt.Errorf("unknown inference mode: %s", mode)
+	}
+	if store == nil && mode != InferenceRemote {
+		return fmt.Errorf("inference mode %s requires exported models", mode)
+	}
+
+	s.modelStore = store
+	s.inferenceMode = mode
+	return nil
+}
+
+// predict получает предсказание выбранным способом
+func (s *PredictionService) predict(ctx context.Context, query model.PredictionQuery, bounds model.Bounds) (*model.Prediction, error) {
+	switch s.inferenceMode {
+	case InferenceLocal:
+		return s.localPrediction(query, bounds)
+	case InferenceBoth:
+		return s.comparePredictions(ctx, query, bounds)
+	default:
+		return s.mlClient.GetPrediction(ctx, query)
+	}
+}
+
+// localPrediction считает предсказание экспортированной моделью в Go
+func (s *PredictionService) localPrediction(query model.PredictionQuery, bounds model.Bounds) (*model.Prediction, error) {
+	if query.Features == nil {
+		return nil, fmt.Errorf("local inference requires features")
+	}
+
+	exported, err := s.exportedModel(query, bounds)
+	if err != nil {
+		return nil, err
+	}
+
+	return exported.Predict(*query.Features)
+}
+
+// exportedModel возвращает модель, названную в запросе, или выбирает ее по области
+func (s *PredictionService) exportedModel(query model.PredictionQuery, bounds model.Bounds) (*inference.Model, error) {
+	if query.Model != "" {
+		return s.modelStore.Get(query.Model)
+	}
+	return s.modelStore.Find(query.ShopType, query.TrainPeriod, bounds)
+}
+
+// comparePredictions запрашивает ML сервис и локальную модель, логирует расхождение
+// и возвращает ответ ML сервиса, а при его ошибке — локальный
+func (s *PredictionService) comparePredictions(ctx context.Context, query model.PredictionQuery, bounds model.Bounds) (*model.Prediction, error) {
+	local, localErr := s.localPrediction(query, bounds)
+	remote, remoteErr := s.mlClient.GetPrediction(ctx, query)
+
+	switch {
+	case remoteErr == nil && localErr == nil:
+		log.Printf("Inference comparison for bbox=%s: remote %s activity=%.4f, local %s activity=%.4f, diff=%.4f",
+			query.BBox, remote.ModelUsed, remote.ActivityLevel, local.ModelUsed, local.ActivityLevel,
+			math.Abs(remote.ActivityLevel-local.ActivityLevel))
+		return remote, nil
+	case remoteErr == nil:
+		log.Printf("Warning: local inference failed for bbox=%s: %v", query.BBox, localErr)
+		return remote, nil
+	case localErr == nil:
+		log.Printf("Warning: ML service failed for bbox=%s, using local model %s: %v", query.BBox, local.ModelUsed, remoteErr)
+		return local, nil
+	default:
+		return nil, fmt.Errorf("%w (local inference: %v)", remoteErr, localErr)
+	}
+}
+
+// explain считает вклады признаков экспортированной моделью, давшей предсказание
+// (prediction.ModelUsed, в том числе кандидатом canary). Если такой модели нет среди
+// экспортированных, модель выбирается так же, как в ML сервисе, и расхождение
+// отмечается в Note. Без экспортированных моделей возвращаются только признаки.
+func (s *PredictionService) explain(query model.PredictionQuery, bounds model.Bounds, prediction *model.Prediction) *model.Explanation {
+	if s.modelStore == nil {
+		return &model.Explanation{
+			Features: query.Features,
+			Note:     "feature contributions require exported models (MODELS_DIR)",
+		}
+	}
+
+	exported, err := s.modelStore.Get(prediction.ModelUsed)
+	if err != nil {
+		exported, err = s.exportedModel(query, bounds)
+	}
+	if err == nil {
+		var explanation *model.Explanation
+		explanation, err = exported.Explain(*query.Features)
+		if err == nil {
+			explanation.Features = query.Features
+			if prediction.ModelUsed != "" && exported.Name != prediction.ModelUsed {
+				explanation.Note = fmt.Sprintf("model %s used for the prediction is not exported, contributions are computed by %s",
+					prediction.ModelUsed, exported.Name)
+			}
+			return explanation
+		}
+	}
+
+	log.Printf("Warning: failed to explain prediction for bbox=%s: %v", query.BBox, err)
+	return &model.Explanation{
+		Features: query.Features,
+		Note:     fmt.Sprintf("feature contributions are unavailable: %v", err),
+	}
+}
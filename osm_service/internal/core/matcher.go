@@ -0,0 +1,233 @@
+package core
+
+import (
+	"osm_service/internal/domain/model"
+	"sort"
+	"strings"
+)
+
+// categoryKeys — теги, определяющие категорию объекта
+var categoryKeys = []string{"shop", "amenity"}
+
+// brandKeys — теги, определяющие бренд объекта
+var brandKeys = []string{"brand", "brand:wikidata"}
+
+// ElementMatcher связывает объекты двух снимков OSM по ID, близости и сходству тегов,
+// чтобы переименование или перенос точки на контур не считались закрытием и открытием
+type ElementMatcher struct {
+	// MaxDistance — максимальное расстояние в метрах между объектами разных ID
+	MaxDistance float64
+	// MinSimilarity — минимальное сходство тегов для объектов разных ID
+	MinSimilarity float64
+}
+
+// NewElementMatcher создает сопоставитель с порогами по умолчанию
+func NewElementMatcher() *ElementMatcher {
+	return &ElementMatcher{
+		MaxDistance:   50,
+		MinSimilarity: 0.5,
+	}
+}
+
+type elementKey struct {
+	Type string
+	ID   int64
+}
+
+type candidatePair struct {
+	before     int
+	after      int
+	distance   float64
+	similarity float64
+}
+
+// Match возвращает события для объектов, которые изменились между снимками.
+// Объекты без изменений тегов в результат не попадают.
+func (m *ElementMatcher) Match(before, after []model.OSMElement) []model.ElementEvent {
+	before = taggedElements(before)
+	after = taggedElements(after)
+
+	var events []model.ElementEvent
+
+	// Сначала связываем объекты с одинаковыми типом и ID
+	afterByKey := make(map[elementKey]int, len(after))
+	for i, el := range after {
+		afterByKey[elementKey{el.Type, el.ID}] = i
+	}
+
+	matchedBefore := make([]bool, len(before))
+	matchedAfter := make([]bool, len(after))
+	for i, el := range before {
+		j, ok := afterByKey[elementKey{el.Type, el.ID}]
+		if !ok {
+			continue
+		}
+		matchedBefore[i] = true
+		matchedAfter[j] = true
+
+		if kind := classifyTagChange(el.Tags, after[j].Tags); kind != "" {
+			events = append(events, m.event(kind, &before[i], &after[j]))
+		}
+	}
+
+	// Затем жадно связываем оставшиеся объекты по расстоянию и сходству тегов
+	var pairs []candidatePair
+	for i, b := range before {
+		if matchedBefore[i] {
+			continue
+		}
+		for j, a := range after {
+			if matchedAfter[j] {
+				continue
+			}
+			distance := calculateDistance(b.Lat, b.Lon, a.Lat, a.Lon)
+			if distance > m.MaxDistance {
+				continue
+			}
+			similarity := tagSimilarity(b.Tags, a.Tags)
+			if similarity < m.MinSimilarity {
+				continue
+			}
+			pairs = append(pairs, candidatePair{i, j, distance, similarity})
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].similarity != pairs[j].similarity {
+			return pairs[i].similarity > pairs[j].similarity
+		}
+		return pairs[i].distance < pairs[j].distance
+	})
+
+	for _, pair := range pairs {
+		if matchedBefore[pair.before] || matchedAfter[pair.after] {
+			continue
+		}
+		matchedBefore[pair.before] = true
+		matchedAfter[pair.after] = true
+
+		b, a := &before[pair.before], &after[pair.after]
+		kind := classifyTagChange(b.Tags, a.Tags)
+		if b.Type != a.Type {
+			kind = model.EventGeometryUpgrade
+		}
+		if kind == "" {
+			// Объект пересоздан с тем же набором тегов
+			continue
+		}
+		events = append(events, model.ElementEvent{
+			Kind:       kind,
+			Before:     b,
+			After:      a,
+			Distance:   pair.distance,
+			Similarity: pair.similarity,
+		})
+	}
+
+	// Все, что осталось без пары, — настоящие открытия и закрытия
+	for i := range before {
+		if !matchedBefore[i] {
+			events = append(events, model.ElementEvent{Kind: model.EventClosure, Before: &before[i]})
+		}
+	}
+	for j := range after {
+		if !matchedAfter[j] {
+			events = append(events, model.ElementEvent{Kind: model.EventOpening, After: &after[j]})
+		}
+	}
+
+	return events
+}
+
+func (m *ElementMatcher) event(kind string, before, after *model.OSMElement) model.ElementEvent {
+	return model.ElementEvent{
+		Kind:       kind,
+		Before:     before,
+		After:      after,
+		Distance:   calculateDistance(before.Lat, before.Lon, after.Lat, after.Lon),
+		Similarity: tagSimilarity(before.Tags, after.Tags),
+	}
+}
+
+// CountEvents считает события по типам
+func CountEvents(events []model.ElementEvent) map[string]int {
+	counts := make(map[string]int)
+	for _, event := range events {
+		counts[event.Kind]++
+	}
+	return counts
+}
+
+// taggedElements отбрасывает узлы геометрии без тегов, которые Overpass
+// возвращает вместе с контурами (out skel)
+func taggedElements(elements []model.OSMElement) []model.OSMElement {
+	result := make([]model.OSMElement, 0, len(elements))
+	for _, el := range elements {
+		if len(el.Tags) > 0 {
+			result = append(result, el)
+		}
+	}
+	return result
+}
+
+// classifyTagChange определяет тип изменения тегов одного и того же объекта.
+// Пустая строка означает, что значимые теги не изменились.
+func classifyTagChange(before, after map[string]string) string {
+	switch {
+	case tagsDiffer(before, after, categoryKeys):
+		return model.EventCategoryChange
+	case tagsDiffer(before, after, brandKeys):
+		return model.EventRebrand
+	case normalizeName(before["name"]) != normalizeName(after["name"]):
+		return model.EventRename
+	default:
+		return ""
+	}
+}
+
+func tagsDiffer(before, after map[string]string, keys []string) bool {
+	for _, key := range keys {
+		if before[key] != after[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// tagSimilarity оценивает сходство двух наборов тегов: совпадение категории
+// весит больше всего, затем название и бренд, остальное — по доле общих тегов
+func tagSimilarity(before, after map[string]string) float64 {
+	var score float64
+
+	if !tagsDiffer(before, after, categoryKeys) {
+		score += 0.4
+	}
+	if name := normalizeName(before["name"]); name != "" && name == normalizeName(after["name"]) {
+		score += 0.3
+	}
+	if brand := before["brand"]; brand != "" && brand == after["brand"] {
+		score += 0.1
+	}
+
+	// Доля совпадающих пар ключ=значение (коэффициент Жаккара)
+	union := make(map[string]struct{})
+	var common int
+	for key, value := range before {
+		union[key] = struct{}{}
+		if after[key] == value {
+			common++
+		}
+	}
+	for key := range after {
+		union[key] = struct{}{}
+	}
+	if len(union) > 0 {
+		score += 0.2 * float64(common) / float64(len(union))
+	}
+
+	return score
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
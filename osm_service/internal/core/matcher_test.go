@@ -0,0 +1,135 @@
+package core
+
+import (
+	"slices"
+	"testing"
+
+	"osm_service/internal/domain/model"
+)
+
+func TestClassifyTagChange(t *testing.T) {
+	tests := []struct {
+		name   string
+		before map[string]string
+		after  map[string]string
+		want   string
+	}{
+		{
+			name:   "unchanged",
+			before: map[string]string{"shop": "bakery", "name": "Хлеб"},
+			after:  map[string]string{"shop": "bakery", "name": "Хлеб", "opening_hours": "08:00-20:00"},
+		},
+		{
+			name:   "name case and spaces",
+			before: map[string]string{"shop": "bakery", "name": "Хлеб  Насущный"},
+			after:  map[string]string{"shop": "bakery", "name": "хлеб насущный"},
+		},
+		{
+			name:   "rename",
+			before: map[string]string{"shop": "bakery", "name": "Хлеб"},
+			after:  map[string]string{"shop": "bakery", "name": "Булочная"},
+			want:   model.EventRename,
+		},
+		{
+			name:   "rebrand",
+			before: map[string]string{"shop": "supermarket", "name": "Пятерочка", "brand": "Пятерочка"},
+			after:  map[string]string{"shop": "supermarket", "name": "Перекресток", "brand": "Перекресток"},
+			want:   model.EventRebrand,
+		},
+		{
+			name:   "brand wikidata",
+			before: map[string]string{"shop": "supermarket", "brand": "Магнит", "brand:wikidata": "Q940518"},
+			after:  map[string]string{"shop": "supermarket", "brand": "Магнит", "brand:wikidata": "Q4057537"},
+			want:   model.EventRebrand,
+		},
+		{
+			name:   "category change",
+			before: map[string]string{"shop": "bakery", "name": "Хлеб"},
+			after:  map[string]string{"amenity": "cafe", "name": "Хлеб"},
+			want:   model.EventCategoryChange,
+		},
+		{
+			name:   "category change wins over rebrand",
+			before: map[string]string{"shop": "convenience", "brand": "Пятерочка"},
+			after:  map[string]string{"shop": "alcohol", "brand": "Красное&Белое"},
+			want:   model.EventCategoryChange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyTagChange(tt.before, tt.after); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestElementMatcherMatch(t *testing.T) {
+	cafe := map[string]string{"amenity": "cafe", "name": "Кофейня"}
+	bakery := map[string]string{"shop": "bakery", "name": "Хлеб"}
+
+	tests := []struct {
+		name   string
+		before []model.OSMElement
+		after  []model.OSMElement
+		want   []string
+	}{
+		{
+			name:   "same element unchanged",
+			before: []model.OSMElement{{ID: 1, Type: "node", Lat: 55.75, Lon: 37.61, Tags: cafe}},
+			after:  []model.OSMElement{{ID: 1, Type: "node", Lat: 55.75, Lon: 37.61, Tags: cafe}},
+		},
+		{
+			name:   "same element renamed",
+			before: []model.OSMElement{{ID: 1, Type: "node", Lat: 55.75, Lon: 37.61, Tags: cafe}},
+			after: []model.OSMElement{{ID: 1, Type: "node", Lat: 55.75, Lon: 37.61,
+				Tags: map[string]string{"amenity": "cafe", "name": "Кофе с собой"}}},
+			want: []string{model.EventRename},
+		},
+		{
+			name:   "node replaced by way at the same location",
+			before: []model.OSMElement{{ID: 1, Type: "node", Lat: 55.75, Lon: 37.61, Tags: cafe}},
+			after:  []model.OSMElement{{ID: 900, Type: "way", Lat: 55.75005, Lon: 37.61005, Tags: cafe}},
+			want:   []string{model.EventGeometryUpgrade},
+		},
+		{
+			name:   "recreated node with same tags",
+			before: []model.OSMElement{{ID: 1, Type: "node", Lat: 55.75, Lon: 37.61, Tags: cafe}},
+			after:  []model.OSMElement{{ID: 2, Type: "node", Lat: 55.75001, Lon: 37.61, Tags: cafe}},
+		},
+		{
+			name:   "far away is closure and opening",
+			before: []model.OSMElement{{ID: 1, Type: "node", Lat: 55.75, Lon: 37.61, Tags: cafe}},
+			after:  []model.OSMElement{{ID: 2, Type: "node", Lat: 55.76, Lon: 37.61, Tags: cafe}},
+			want:   []string{model.EventClosure, model.EventOpening},
+		},
+		{
+			name:   "different category nearby is closure and opening",
+			before: []model.OSMElement{{ID: 1, Type: "node", Lat: 55.75, Lon: 37.61, Tags: cafe}},
+			after:  []model.OSMElement{{ID: 2, Type: "node", Lat: 55.75, Lon: 37.61, Tags: bakery}},
+			want:   []string{model.EventClosure, model.EventOpening},
+		},
+		{
+			name:   "untagged geometry nodes ignored",
+			before: []model.OSMElement{{ID: 1, Type: "node", Lat: 55.75, Lon: 37.61, Tags: cafe}},
+			after: []model.OSMElement{
+				{ID: 1, Type: "node", Lat: 55.75, Lon: 37.61, Tags: cafe},
+				{ID: 5, Type: "node", Lat: 55.7501, Lon: 37.6101},
+			},
+		},
+	}
+
+	matcher := NewElementMatcher()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var kinds []string
+			for _, event := range matcher.Match(tt.before, tt.after) {
+				kinds = append(kinds, event.Kind)
+			}
+			if !slices.Equal(kinds, tt.want) {
+				t.Errorf("expected events %v, got %v", tt.want, kinds)
+			}
+		})
+	}
+}
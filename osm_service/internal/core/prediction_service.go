@@ -7,8 +7,8 @@ import (
 	"math"
 	"osm_service/internal/domain/model"
 	"osm_service/internal/domain/repository"
-	"strconv"
-	"strings"
+	"osm_service/internal/forecast"
+	"osm_service/internal/inference"
 	"time"
 )
 
@@ -18,6 +18,21 @@ type PredictionService struct {
 	mlClient         model.MLClient
 	trainingRecorder repository.TrainingDataRecorder
 	saveData         bool
+
+	forecastMethod    string
+	forecastBenchmark bool
+
+	modelStore    *inference.Store
+	inferenceMode string
+
+	modelRegistry repository.ModelRegistry
+	predictionLog repository.PredictionLog
+	rollout       *model.Rollout
+	shadowSlots   chan struct{}
+
+	datasetConcurrency int
+	batchConcurrency   int
+	datasetCheckpoints repository.DatasetCheckpoints
 }
 
 func NewPredictionService(
@@ -33,6 +48,8 @@ func NewPredictionService(
 		mlClient:         mlClient,
 		trainingRecorder: recorder,
 		saveData:         saveData,
+		forecastMethod:   forecast.MethodAuto,
+		inferenceMode:    InferenceRemote,
 	}
 }
 
@@ -61,31 +78,10 @@ func (s *PredictionService) getHistoricalData(
 		return nil, fmt.Errorf("failed to get end date data: %w", err)
 	}
 
-	// Создаем карты для быстрого поиска
-	startSet := make(map[int64]struct{})
-	for _, el := range startElements {
-		startSet[el.ID] = struct{}{}
-	}
-
-	endSet := make(map[int64]struct{})
-	for _, el := range endElements {
-		endSet[el.ID] = struct{}{}
-	}
-
-	// Вычисляем новые и закрытые объекты
-	newObjects := 0
-	for id := range endSet {
-		if _, exists := startSet[id]; !exists {
-			newObjects++
-		}
-	}
-
-	closedObjects := 0
-	for id := range startSet {
-		if _, exists := endSet[id]; !exists {
-			closedObjects++
-		}
-	}
+	// Сопоставляем объекты, чтобы переименования не считались закрытием и открытием
+	counts := CountEvents(NewElementMatcher().Match(startElements, endElements))
+	newObjects := counts[model.EventOpening]
+	closedObjects := counts[model.EventClosure]
 
 	// Формируем исторические данные
 	return []model.HistoricalData{
@@ -102,6 +98,7 @@ func (s *PredictionService) getHistoricalData(
 			TotalObjects:  len(endElements),
 			NewObjects:    newObjects,
 			ClosedObjects: closedObjects,
+			Events:        counts,
 		},
 	}, nil
 }
@@ -141,18 +138,8 @@ func (s *PredictionService) calculateFeatures(
 		if len(historical) > 0 {
 			// Парсим bbox из исторических данных
 			bbox := historical[0].Period // Предполагаем, что Period содержит bbox
-			parts := strings.Split(bbox, ",")
-			if len(parts) == 4 {
-				minLat, _ := strconv.ParseFloat(parts[0], 64)
-				minLon, _ := strconv.ParseFloat(parts[1], 64)
-				maxLat, _ := strconv.ParseFloat(parts[2], 64)
-				maxLon, _ := strconv.ParseFloat(parts[3], 64)
-				bounds = model.Bounds{
-					MinLat: minLat,
-					MinLon: minLon,
-					MaxLat: maxLat,
-					MaxLon: maxLon,
-				}
+			if parsed, err := model.ParseBounds(bbox); err == nil {
+				bounds = parsed
 			}
 		}
 	}
@@ -220,7 +207,37 @@ func (s *PredictionService) GetCurrentData(ctx context.Context, bounds model.Bou
 		return nil, fmt.Errorf("failed to get subways: %w", err)
 	}
 
-	// Рассчитываем дополнительные характеристики для каждого объекта
+	enrichElements(elements, roads, subways)
+	return elements, nil
+}
+
+// GetDataByDate возвращает коммерческие объекты и расстояния до дорог и метро
+// по состоянию OSM на дату, без данных, появившихся позже
+func (s *PredictionService) GetDataByDate(ctx context.Context, bounds model.Bounds, shopType string, date time.Time) ([]model.OSMElement, error) {
+	bbox := fmt.Sprintf("%f,%f,%f,%f", bounds.MinLat, bounds.MinLon, bounds.MaxLat, bounds.MaxLon)
+	dateStr := date.Format("2006-01-02T15:04:05Z")
+
+	elements, err := s.overpassRepo.GetCommercialDataByDate(ctx, bbox, shopType, dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commercial data: %w", err)
+	}
+
+	roads, err := s.overpassRepo.GetRoadData(ctx, bbox, dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roads: %w", err)
+	}
+
+	subways, err := s.overpassRepo.GetSubwayData(ctx, bbox, dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subways: %w", err)
+	}
+
+	enrichElements(elements, roads, subways)
+	return elements, nil
+}
+
+// enrichElements рассчитывает дополнительные характеристики для каждого объекта
+func enrichElements(elements, roads, subways []model.OSMElement) {
 	for i := range elements {
 		// Рассчитываем площадь
 		if building, ok := elements[i].Tags["building"]; ok && building != "" {
@@ -258,8 +275,6 @@ func (s *PredictionService) GetCurrentData(ctx context.Context, bounds model.Bou
 		}
 		elements[i].DistToSubway = minDistToSubway
 	}
-
-	return elements, nil
 }
 
 // calculateDistance рассчитывает расстояние между двумя точками в метрах
@@ -286,7 +301,7 @@ func (s *PredictionService) CalculateFeatures(ctx context.Context, current []mod
 	return s.calculateFeatures(ctx, current, historical, years)
 }
 
-// GetHistoricalDataForPeriod retrieves historical data for a specific cluster and time period
+// GetHistoricalDataForPeriod retrieves yearly historical data for a specific cluster and time period
 func (s *PredictionService) GetHistoricalDataForPeriod(
 	ctx context.Context,
 	bounds model.Bounds,
@@ -294,57 +309,63 @@ func (s *PredictionService) GetHistoricalDataForPeriod(
 	endDate time.Time,
 	shopType string,
 ) ([]model.HistoricalData, error) {
+	return s.GetHistoricalDataSampled(ctx, bounds, startDate, endDate, shopType, 12)
+}
+
+// GetHistoricalDataSampled retrieves historical data for a cluster with a snapshot every stepMonths months
+func (s *PredictionService) GetHistoricalDataSampled(
+	ctx context.Context,
+	bounds model.Bounds,
+	startDate time.Time,
+	endDate time.Time,
+	shopType string,
+	stepMonths int,
+) ([]model.HistoricalData, error) {
+	if stepMonths <= 0 {
+		return nil, fmt.Errorf("step must be positive, got %d months", stepMonths)
+	}
+
 	bbox := fmt.Sprintf("%f,%f,%f,%f", bounds.MinLat, bounds.MinLon, bounds.MaxLat, bounds.MaxLon)
 
 	// Создаем слайс для хранения исторических данных
 	var historicalData []model.HistoricalData
+	var prevElements []model.OSMElement
+	matcher := NewElementMatcher()
 
-	// Получаем данные за каждый год в периоде
+	// Получаем данные на каждую дату в периоде
 	currentDate := startDate
 	for currentDate.Before(endDate) || currentDate.Equal(endDate) {
 		// Форматируем дату для Overpass
 		dateStr := currentDate.Format("2006-01-02T15:04:05Z")
 
-		// Получаем данные за текущий год
+		// Получаем данные на текущую дату
 		elements, err := s.overpassRepo.GetCommercialDataByDate(ctx, bbox, shopType, dateStr)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get data for date %s: %w", dateStr, err)
 		}
 
-		// Если это не первый год, вычисляем новые и закрытые объекты
+		// Если это не первая дата, сопоставляем объекты с предыдущим снимком
 		var newObjects, closedObjects int
+		var counts map[string]int
 		if len(historicalData) > 0 {
-			prevElementsSet := make(map[int64]struct{})
-			for _, el := range elements {
-				prevElementsSet[el.ID] = struct{}{}
-			}
-
-			// Вычисляем новые объекты
-			for id := range prevElementsSet {
-				if _, exists := prevElementsSet[id]; !exists {
-					newObjects++
-				}
-			}
-
-			// Вычисляем закрытые объекты
-			for id := range prevElementsSet {
-				if _, exists := prevElementsSet[id]; !exists {
-					closedObjects++
-				}
-			}
+			counts = CountEvents(matcher.Match(prevElements, elements))
+			newObjects = counts[model.EventOpening]
+			closedObjects = counts[model.EventClosure]
 		}
 
-		// Добавляем данные за текущий год
+		// Добавляем данные на текущую дату
 		historicalData = append(historicalData, model.HistoricalData{
 			Period:        currentDate.Format("2006-01-02"),
 			BBox:          bbox,
 			TotalObjects:  len(elements),
 			NewObjects:    newObjects,
 			ClosedObjects: closedObjects,
+			Events:        counts,
 		})
+		prevElements = elements
 
-		// Переходим к следующему году
-		currentDate = currentDate.AddDate(1, 0, 0)
+		// Переходим к следующей дате
+		currentDate = currentDate.AddDate(0, stepMonths, 0)
 	}
 
 	return historicalData, nil
@@ -406,20 +427,163 @@ func (s *PredictionService) CalculateFeaturesForPeriod(
 }
 
 // GetAvailableModels возвращает список доступных моделей
-func (s *PredictionService) GetAvailableModels() ([]model.ModelInfo, error) {
-	return s.mlClient.GetAvailableModels()
+func (s *PredictionService) GetAvailableModels(ctx context.Context) ([]model.ModelInfo, error) {
+	return s.mlClient.GetAvailableModels(ctx)
 }
 
-// GetPrediction получает предсказание для указанной области
-func (s *PredictionService) GetPrediction(bbox string, shopType string, predictionYear string, trainPeriod string) (*model.Prediction, error) {
-	// Формируем имя модели
-	modelName := fmt.Sprintf("model_%s_%s", shopType, trainPeriod)
+// GetPrediction получает предсказание для указанной области.
+// Признаки области считаются тем же кодом, что и датасет в Handler.Training.
+// Если модель недоступна или не найдена, используется прогноз ряда в Go.
+func (s *PredictionService) GetPrediction(ctx context.Context, bbox string, shopType string, predictionYear string, trainPeriod string) (*model.Prediction, error) {
+	prediction, _, err := s.getPrediction(ctx, bbox, shopType, predictionYear, trainPeriod, false)
+	return prediction, err
+}
 
-	// Получаем предсказание от ML сервиса
-	prediction, err := s.mlClient.GetPrediction(bbox, modelName, predictionYear)
+// ExplainPrediction возвращает предсказание вместе с признаками и их вкладами
+func (s *PredictionService) ExplainPrediction(ctx context.Context, bbox string, shopType string, predictionYear string, trainPeriod string) (*model.Prediction, error) {
+	prediction, _, err := s.getPrediction(ctx, bbox, shopType, predictionYear, trainPeriod, true)
+	return prediction, err
+}
+
+func (s *PredictionService) getPrediction(
+	ctx context.Context,
+	bbox string,
+	shopType string,
+	predictionYear string,
+	trainPeriod string,
+	explain bool,
+) (*model.Prediction, *model.FeatureSet, error) {
+	bounds, err := parseBounds(bbox)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resolved, err := s.resolveModel(ctx, bbox, shopType, trainPeriod)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get prediction: %w", err)
+		return nil, nil, err
+	}
+	startDate, endDate := resolved.TrainStart, resolved.TrainEnd
+
+	// История снимается с тем же шагом, что и датасет обучения модели,
+	// иначе сезонные признаки не совпадут с признаками обучения
+	historical, err := s.GetHistoricalDataSampled(ctx, bounds, startDate, endDate, shopType, resolved.SamplingStep())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get historical data: %w", err)
+	}
+
+	featureSet, features, err := s.areaFeatures(ctx, bounds, historical, startDate, endDate, shopType)
+	if err != nil {
+		log.Printf("Warning: failed to calculate features, falling back to forecast: %v", err)
+		prediction, err := s.forecastPrediction(historical, startDate, endDate, predictionYear)
+		if err != nil {
+			return nil, nil, err
+		}
+		prediction.Fallback = true
+		addUncertainty(prediction, historical, nil, startDate, endDate, true)
+		if explain {
+			prediction.Explanation = &model.Explanation{Note: "features are unavailable, prediction is based on the object count series"}
+		}
+		return prediction, featureSet, nil
+	}
+
+	// ML сервис ожидает период обучения в формате "2019-2023"
+	query := model.PredictionQuery{
+		BBox:           bbox,
+		ShopType:       shopType,
+		PredictionYear: predictionYear,
+		TrainPeriod:    fmt.Sprintf("%d-%d", startDate.Year(), endDate.Year()),
+		Features:       &features,
+		Model:          resolved.Name,
+	}
+
+	// Получаем предсказание от ML сервиса и/или экспортированной модели
+	prediction, err := s.predictWithRollout(ctx, query, bounds, resolved.SamplingStep())
+	if err != nil {
+		if !canFallBack(ctx, err) {
+			return nil, nil, fmt.Errorf("failed to get prediction: %w", err)
+		}
+		log.Printf("Warning: model prediction failed, falling back to forecast: %v", err)
+
+		fallback, fallbackErr := s.forecastPrediction(historical, startDate, endDate, predictionYear)
+		if fallbackErr != nil {
+			return nil, nil, fmt.Errorf("failed to get prediction: %w (forecast fallback: %v)", err, fallbackErr)
+		}
+		fallback.Fallback = true
+		addUncertainty(fallback, historical, &features, startDate, endDate, true)
+		if explain {
+			fallback.Explanation = &model.Explanation{
+				Features: &features,
+				Note:     "model is unavailable, prediction is based on the object count series",
+			}
+		}
+		return fallback, featureSet, nil
+	}
+
+	if s.forecastBenchmark {
+		s.benchmarkForecast(prediction, historical, startDate, endDate, predictionYear)
+	}
+
+	addUncertainty(prediction, historical, &features, startDate, endDate, false)
+	if explain {
+		prediction.Explanation = s.explain(query, bounds, prediction)
+	}
+	return prediction, featureSet, nil
+}
+
+// predictionFeatures считает признаки последнего года периода обучения —
+// ту же строку, что попадает в датасет для этого кластера
+func (s *PredictionService) predictionFeatures(
+	ctx context.Context,
+	bounds model.Bounds,
+	historical []model.HistoricalData,
+	startDate time.Time,
+	endDate time.Time,
+	shopType string,
+) (model.FeatureVector, error) {
+	_, row, err := s.areaFeatures(ctx, bounds, historical, startDate, endDate, shopType)
+	return row, err
+}
+
+// areaFeatures считает пространственные и временные признаки области и строку признаков
+// последнего года периода. Набор признаков равен nil, если не удалось получить объекты.
+func (s *PredictionService) areaFeatures(
+	ctx context.Context,
+	bounds model.Bounds,
+	historical []model.HistoricalData,
+	startDate time.Time,
+	endDate time.Time,
+	shopType string,
+) (*model.FeatureSet, model.FeatureVector, error) {
+	current, err := s.GetCurrentData(ctx, bounds, shopType)
+	if err != nil {
+		return nil, model.FeatureVector{}, fmt.Errorf("failed to get current data: %w", err)
+	}
+
+	features := s.CalculateFeaturesForPeriod(ctx, current, historical, startDate, endDate, bounds)
+	row, err := lastYearRow(features, historical, startDate, endDate)
+	return &features, row, err
+}
+
+// featureRow считает строку признаков последнего года периода по переданным объектам
+func (s *PredictionService) featureRow(
+	ctx context.Context,
+	current []model.OSMElement,
+	bounds model.Bounds,
+	historical []model.HistoricalData,
+	startDate time.Time,
+	endDate time.Time,
+) (model.FeatureVector, error) {
+	features := s.CalculateFeaturesForPeriod(ctx, current, historical, startDate, endDate, bounds)
+	return lastYearRow(features, historical, startDate, endDate)
+}
+
+// lastYearRow возвращает признаки последнего года периода в формате датасета
+func lastYearRow(features model.FeatureSet, historical []model.HistoricalData, startDate, endDate time.Time) (model.FeatureVector, error) {
+	yearly := YearlyFeatures(features, historical, startDate, endDate)
+	if len(yearly) == 0 {
+		return model.FeatureVector{}, fmt.Errorf("no yearly features for period %s - %s",
+			startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
 	}
 
-	return prediction, nil
+	return yearly[len(yearly)-1].Features, nil
 }
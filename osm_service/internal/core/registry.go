@@ -0,0 +1,131 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"osm_service/internal/domain/model"
+	"osm_service/internal/domain/repository"
+)
+
+// ErrRegistryDisabled возвращается, если реестр моделей не подключен
+var ErrRegistryDisabled = fmt.Errorf("model registry is %w", ErrNotConfigured)
+
+// SetModelRegistry подключает реестр моделей. Если он задан, модель для
+// предсказания без явного периода обучения выбирается по реестру.
+func (s *PredictionService) SetModelRegistry(registry repository.ModelRegistry) {
+	s.modelRegistry = registry
+}
+
+// ListModels возвращает модели из реестра
+func (s *PredictionService) ListModels(ctx context.Context, filter model.ModelFilter) ([]model.RegisteredModel, error) {
+	if s.modelRegistry == nil {
+		return nil, ErrRegistryDisabled
+	}
+	return s.modelRegistry.ListModels(ctx, filter)
+}
+
+// RegisterModel проверяет метаданные модели и добавляет ее в реестр
+func (s *PredictionService) RegisterModel(ctx context.Context, m model.RegisteredModel) (model.RegisteredModel, error) {
+	if s.modelRegistry == nil {
+		return model.RegisteredModel{}, ErrRegistryDisabled
+	}
+
+	if m.Status == "" {
+		m.Status = model.ModelStatusCandidate
+	}
+	if m.FeatureSchemaVersion == "" {
+		m.FeatureSchemaVersion = model.FeatureSchemaVersion
+	}
+	if m.SamplingMonths == 0 {
+		m.SamplingMonths = model.DefaultSamplingMonths
+	}
+	if err := validateRegisteredModel(m); err != nil {
+		return model.RegisteredModel{}, err
+	}
+
+	return s.modelRegistry.RegisterModel(ctx, m)
+}
+
+// UpdateModelStatus меняет статус модели в реестре: например, активирует кандидата
+// после проверки или выводит модель из эксплуатации
+func (s *PredictionService) UpdateModelStatus(ctx context.Context, name, status string) (model.RegisteredModel, error) {
+	if s.modelRegistry == nil {
+		return model.RegisteredModel{}, ErrRegistryDisabled
+	}
+	if !validModelStatus(status) {
+		return model.RegisteredModel{}, invalidField("status", "unknown model status %q", status)
+	}
+	return s.modelRegistry.UpdateStatus(ctx, name, status)
+}
+
+// FindModel выбирает из реестра активную модель, лучше всего покрывающую область
+func (s *PredictionService) FindModel(ctx context.Context, category, bbox string) (model.RegisteredModel, error) {
+	if s.modelRegistry == nil {
+		return model.RegisteredModel{}, ErrRegistryDisabled
+	}
+	if _, err := parseBounds(bbox); err != nil {
+		return model.RegisteredModel{}, err
+	}
+	return s.modelRegistry.FindBestModel(ctx, category, bbox)
+}
+
+func validateRegisteredModel(m model.RegisteredModel) error {
+	var validation ValidationError
+	if m.Name == "" {
+		validation.Add("name", "is required")
+	}
+	if m.Category == "" {
+		validation.Add("category", "is required")
+	}
+	if m.TrainStart.IsZero() || m.TrainEnd.IsZero() || !m.TrainStart.Before(m.TrainEnd) {
+		validation.Add("train_end", "must be after train_start")
+	}
+	if _, err := parseBounds(m.Coverage); err != nil {
+		validation.Add("coverage", "expected \"minLat,minLon,maxLat,maxLon\", got %q", m.Coverage)
+	}
+	if !validSamplingMonths(m.SamplingMonths) {
+		validation.Add("sampling_months", "must divide a year: 1, 2, 3, 4, 6 or 12, got %d", m.SamplingMonths)
+	}
+	if !validModelStatus(m.Status) {
+		validation.Add("status", "unknown model status %q", m.Status)
+	}
+	return validation.Err()
+}
+
+func validModelStatus(status string) bool {
+	switch status {
+	case model.ModelStatusCandidate, model.ModelStatusActive, model.ModelStatusRetired:
+		return true
+	default:
+		return false
+	}
+}
+
+// resolveModel возвращает период обучения из запроса, а если он не задан — модель,
+// которую реестр выбрал для области. Имя модели передается в запросе предсказания,
+// чтобы ML сервис и локальный инференс использовали эту же модель, а по ее шагу
+// снимков считаются признаки. Для явного периода имя пустое, а шаг — годовой.
+func (s *PredictionService) resolveModel(ctx context.Context, bbox, shopType, trainPeriod string) (model.RegisteredModel, error) {
+	if trainPeriod != "" {
+		start, end, err := parseTrainPeriod(trainPeriod)
+		return model.RegisteredModel{TrainStart: start, TrainEnd: end}, err
+	}
+	if s.modelRegistry == nil {
+		return model.RegisteredModel{}, invalidField("train_period", "is required when the model registry is not configured")
+	}
+
+	registered, err := s.modelRegistry.FindBestModel(ctx, shopType, bbox)
+	if err != nil {
+		return model.RegisteredModel{}, err
+	}
+	log.Printf("Using registered model %s (%s, every %d months) for bbox=%s",
+		registered.Name, registered.TrainPeriod(), registered.SamplingStep(), bbox)
+	return registered, nil
+}
+
+// validSamplingMonths проверяет, что шаг снимков делит год: иначе снимки разных
+// лет попадают на разные фазы сезонного цикла
+func validSamplingMonths(months int) bool {
+	return months > 0 && months <= 12 && 12%months == 0
+}
@@ -0,0 +1,237 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+	mathrand "math/rand/v2"
+	"osm_service/internal/domain/model"
+	"osm_service/internal/domain/repository"
+	"time"
+)
+
+// shadowTimeout ограничивает время теневого предсказания, которое идет после ответа клиенту
+const shadowTimeout = 2 * time.Minute
+
+// maxShadowPredictions ограничивает число одновременных теневых предсказаний. Если все
+// слоты заняты, теневое предсказание для запроса пропускается, а не копится в памяти.
+const maxShadowPredictions = 8
+
+type requestIDKey struct{}
+
+// ErrPredictionLogDisabled возвращается, если журнал предсказаний не подключен
+var ErrPredictionLogDisabled = fmt.Errorf("prediction log is %w", ErrNotConfigured)
+
+// SetPredictionLog подключает журнал, в который сохраняются предсказания моделей
+func (s *PredictionService) SetPredictionLog(predictionLog repository.PredictionLog) {
+	s.predictionLog = predictionLog
+}
+
+// SetRollout включает теневую или canary-выкатку модели-кандидата из реестра.
+// Для выкатки нужны реестр моделей и журнал предсказаний.
+func (s *PredictionService) SetRollout(rollout model.Rollout) error {
+	switch rollout.Mode {
+	case model.RolloutShadow:
+	case model.RolloutCanary:
+		if rollout.Percent <= 0 || rollout.Percent > 100 {
+			return fmt.Errorf("canary percent must be in (0, 100], got %v", rollout.Percent)
+		}
+	default:
+		return fmt.Errorf("unknown rollout mode: %s", rollout.Mode)
+	}
+	if rollout.Candidate == "" {
+		return fmt.Errorf("rollout candidate is required")
+	}
+	if s.modelRegistry == nil {
+		return fmt.Errorf("rollout requires model registry: %w", ErrRegistryDisabled)
+	}
+	if s.predictionLog == nil {
+		return fmt.Errorf("rollout requires %w", ErrPredictionLogDisabled)
+	}
+
+	s.rollout = &rollout
+	s.shadowSlots = make(chan struct{}, maxShadowPredictions)
+	return nil
+}
+
+// SaveGroundTruth сохраняет фактические изменения, с которыми сверяются предсказания
+func (s *PredictionService) SaveGroundTruth(ctx context.Context, truth model.GroundTruth) error {
+	if s.predictionLog == nil {
+		return ErrPredictionLogDisabled
+	}
+	var validation ValidationError
+	if _, err := parseBounds(truth.BBox); err != nil {
+		validation.Add("bbox", "expected \"minLat,minLon,maxLat,maxLon\", got %q", truth.BBox)
+	}
+	if truth.ShopType == "" {
+		validation.Add("shop_type", "is required")
+	}
+	if truth.Year == "" {
+		validation.Add("year", "is required")
+	}
+	if err := validation.Err(); err != nil {
+		return err
+	}
+	return s.predictionLog.SaveGroundTruth(ctx, truth)
+}
+
+// CompareModels возвращает точность моделей по фактам и расхождение теневых предсказаний с основными
+func (s *PredictionService) CompareModels(ctx context.Context) (*model.ModelComparison, error) {
+	if s.predictionLog == nil {
+		return nil, ErrPredictionLogDisabled
+	}
+
+	accuracy, err := s.predictionLog.ModelAccuracy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	divergence, err := s.predictionLog.ModelDivergence(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ModelComparison{
+		Rollout:    s.rollout,
+		Accuracy:   accuracy,
+		Divergence: divergence,
+	}, nil
+}
+
+// predictWithRollout получает предсказание текущей модели или, при выкатке, кандидата
+// и сохраняет результаты в журнал предсказаний под идентификатором запроса API
+// (см. WithRequestID). stepMonths — шаг снимков, с которым посчитаны признаки запроса.
+func (s *PredictionService) predictWithRollout(ctx context.Context, query model.PredictionQuery, bounds model.Bounds, stepMonths int) (*model.Prediction, error) {
+	requestID := RequestID(ctx)
+	if requestID == "" {
+		requestID = NewRequestID()
+	}
+
+	candidate, candidateName, ok := s.candidateQuery(ctx, query, bounds, stepMonths)
+	if ok && s.rollout.Mode == model.RolloutCanary && mathrand.Float64()*100 < s.rollout.Percent {
+		prediction, err := s.predict(ctx, candidate, bounds)
+		if err == nil {
+			s.recordPrediction(ctx, requestID, model.RoleCanary, candidateName, query, prediction)
+			return prediction, nil
+		}
+		log.Printf("Warning: canary model %s failed, using current model: %v", candidateName, err)
+	}
+
+	prediction, err := s.predict(ctx, query, bounds)
+	if err != nil {
+		return nil, err
+	}
+	s.recordPrediction(ctx, requestID, model.RolePrimary, prediction.ModelUsed, query, prediction)
+
+	if ok && s.rollout.Mode == model.RolloutShadow {
+		select {
+		case s.shadowSlots <- struct{}{}:
+		default:
+			log.Printf("Warning: skipping shadow prediction of %s for bbox=%s: %d shadow predictions are running",
+				candidateName, query.BBox, maxShadowPredictions)
+			return prediction, nil
+		}
+
+		// Теневое предсказание не должно задерживать ответ и не отменяется вместе с запросом
+		go func() {
+			defer func() { <-s.shadowSlots }()
+			shadowCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shadowTimeout)
+			defer cancel()
+
+			shadow, err := s.predict(shadowCtx, candidate, bounds)
+			if err != nil {
+				log.Printf("Warning: shadow model %s failed for bbox=%s: %v", candidateName, query.BBox, err)
+				return
+			}
+			s.recordPrediction(shadowCtx, requestID, model.RoleShadow, candidateName, query, shadow)
+		}()
+	}
+
+	return prediction, nil
+}
+
+// candidateQuery строит запрос к модели-кандидату: те же признаки, но модель кандидата
+// по имени и его период обучения. Без имени ML сервис выбрал бы модель по области,
+// и под именем кандидата в журнал попали бы предсказания основной модели.
+// Возвращает false, если выкатки нет или кандидат не подходит к запросу: другая категория,
+// покрытие кандидата не содержит область (как при выборе модели в реестре) или кандидат
+// обучен на снимках с другим шагом и признаки запроса ему не подходят.
+func (s *PredictionService) candidateQuery(ctx context.Context, query model.PredictionQuery, bounds model.Bounds, stepMonths int) (model.PredictionQuery, string, bool) {
+	if s.rollout == nil {
+		return model.PredictionQuery{}, "", false
+	}
+
+	registered, err := s.modelRegistry.GetModel(ctx, s.rollout.Candidate)
+	if err != nil {
+		log.Printf("Warning: rollout candidate is unavailable: %v", err)
+		return model.PredictionQuery{}, "", false
+	}
+	if registered.Category != query.ShopType {
+		return model.PredictionQuery{}, "", false
+	}
+	coverage, err := parseBounds(registered.Coverage)
+	if err != nil {
+		log.Printf("Warning: rollout candidate %s has invalid coverage: %v", registered.Name, err)
+		return model.PredictionQuery{}, "", false
+	}
+	if !covers(coverage, bounds) {
+		return model.PredictionQuery{}, "", false
+	}
+	if registered.SamplingStep() != stepMonths {
+		log.Printf("Warning: rollout candidate %s is trained on %d-month snapshots, request features use %d-month snapshots",
+			registered.Name, registered.SamplingStep(), stepMonths)
+		return model.PredictionQuery{}, "", false
+	}
+
+	candidate := query
+	candidate.TrainPeriod = registered.TrainPeriod()
+	candidate.Model = registered.Name
+	return candidate, registered.Name, true
+}
+
+func (s *PredictionService) recordPrediction(
+	ctx context.Context,
+	requestID string,
+	role string,
+	modelName string,
+	query model.PredictionQuery,
+	prediction *model.Prediction,
+) {
+	if s.predictionLog == nil {
+		return
+	}
+
+	err := s.predictionLog.SavePrediction(ctx, model.PredictionRecord{
+		RequestID:       requestID,
+		Role:            role,
+		ModelName:       modelName,
+		BBox:            query.BBox,
+		ShopType:        query.ShopType,
+		PredictionYear:  query.PredictionYear,
+		ActivityLevel:   prediction.ActivityLevel,
+		PredictedNew:    prediction.PredictedNew,
+		PredictedClosed: prediction.PredictedClosed,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to record %s prediction of %s: %v", role, modelName, err)
+	}
+}
+
+// WithRequestID сохраняет в контексте идентификатор запроса API, под которым
+// предсказания попадают в журнал
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID возвращает идентификатор запроса из контекста или пустую строку
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID возвращает случайный идентификатор запроса
+func NewRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
@@ -0,0 +1,219 @@
+package core
+
+import (
+	"math"
+	"osm_service/internal/domain/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// seasonalIterations — число итераций уточнения тренда и сезонности
+const seasonalIterations = 2
+
+// Decomposition — разложение ряда на тренд, сезонность и остаток
+type Decomposition struct {
+	SeasonLength int       // число периодов в сезонном цикле (12 для месяцев, 4 для кварталов, 12/шаг в общем случае)
+	Trend        []float64 // тренд
+	Seasonal     []float64 // сезонная составляющая
+	Remainder    []float64 // остаток
+}
+
+// Deseasonalized возвращает ряд без сезонной составляющей
+func (d Decomposition) Deseasonalized() []float64 {
+	result := make([]float64, len(d.Trend))
+	for i := range result {
+		result[i] = d.Trend[i] + d.Remainder[i]
+	}
+	return result
+}
+
+// Amplitude возвращает размах сезонной составляющей
+func (d Decomposition) Amplitude() float64 {
+	if len(d.Seasonal) == 0 {
+		return 0
+	}
+	minValue, maxValue := d.Seasonal[0], d.Seasonal[0]
+	for _, v := range d.Seasonal[1:] {
+		minValue = math.Min(minValue, v)
+		maxValue = math.Max(maxValue, v)
+	}
+	return maxValue - minValue
+}
+
+// Decompose раскладывает ряд по аналогии с STL: тренд оценивается скользящим
+// средним по длине сезона, сезонность — средним отклонением от тренда в каждой
+// фазе цикла, после чего оценки уточняются по ряду без сезонности.
+// positions задает фазу цикла каждого наблюдения (например, месяц - 1).
+func Decompose(series []float64, positions []int, seasonLength int) (Decomposition, bool) {
+	n := len(series)
+	// Для оценки сезонности нужно минимум два полных цикла
+	if seasonLength < 2 || n < 2*seasonLength || len(positions) != n {
+		return Decomposition{}, false
+	}
+
+	seasonal := make([]float64, n)
+	var trend []float64
+	for iter := 0; iter < seasonalIterations; iter++ {
+		adjusted := make([]float64, n)
+		for i := range series {
+			adjusted[i] = series[i] - seasonal[i]
+		}
+		trend = centeredMovingAverage(adjusted, seasonLength)
+
+		// Среднее отклонение от тренда в каждой фазе цикла
+		sums := make([]float64, seasonLength)
+		counts := make([]int, seasonLength)
+		for i := range series {
+			sums[positions[i]] += series[i] - trend[i]
+			counts[positions[i]]++
+		}
+		indexes := make([]float64, seasonLength)
+		var total float64
+		var observed int
+		for p := range indexes {
+			if counts[p] > 0 {
+				indexes[p] = sums[p] / float64(counts[p])
+				total += indexes[p]
+				observed++
+			}
+		}
+		// Центрируем сезонность, чтобы она не смещала уровень ряда
+		if observed > 0 {
+			offset := total / float64(observed)
+			for p := range indexes {
+				if counts[p] > 0 {
+					indexes[p] -= offset
+				}
+			}
+		}
+
+		for i := range series {
+			seasonal[i] = indexes[positions[i]]
+		}
+	}
+
+	remainder := make([]float64, n)
+	for i := range series {
+		remainder[i] = series[i] - trend[i] - seasonal[i]
+	}
+
+	return Decomposition{
+		SeasonLength: seasonLength,
+		Trend:        trend,
+		Seasonal:     seasonal,
+		Remainder:    remainder,
+	}, true
+}
+
+// centeredMovingAverage считает центрированное скользящее среднее (2×m для четного m),
+// у краев ряда окно усекается
+func centeredMovingAverage(series []float64, window int) []float64 {
+	n := len(series)
+	half := window / 2
+	result := make([]float64, n)
+
+	for i := range series {
+		var sum, weights float64
+		for k := -half; k <= half; k++ {
+			j := i + k
+			if j < 0 || j >= n {
+				continue
+			}
+			w := 1.0
+			// Для четного окна крайние точки берутся с половинным весом
+			if window%2 == 0 && (k == -half || k == half) {
+				w = 0.5
+			}
+			sum += w * series[j]
+			weights += w
+		}
+		result[i] = sum / weights
+	}
+
+	return result
+}
+
+// daysPerMonth — средняя длина месяца для перевода шага между снимками в месяцы
+const daysPerMonth = 365.25 / 12
+
+// seasonalPositions определяет длину сезонного цикла по шагу между периодами
+// и фазу каждого наблюдения. Шаг в месяцах должен делить год: длина цикла равна
+// 12/шаг (12 для месяцев, 6 для двух месяцев, 4 для кварталов и т. д.). Для годовых
+// данных и шагов, не кратных году, сезонность не определяется.
+func seasonalPositions(data []model.HistoricalData) ([]int, int, bool) {
+	if len(data) < 2 {
+		return nil, 0, false
+	}
+
+	dates := make([]time.Time, len(data))
+	for i, item := range data {
+		date, ok := parsePeriod(item.Period)
+		if !ok {
+			return nil, 0, false
+		}
+		dates[i] = date
+	}
+
+	gaps := make([]float64, len(dates)-1)
+	for i := 1; i < len(dates); i++ {
+		gaps[i-1] = dates[i].Sub(dates[i-1]).Hours() / 24
+	}
+	step := max(int(math.Round(median(gaps)/daysPerMonth)), 1)
+	if step >= 12 || 12%step != 0 {
+		return nil, 0, false
+	}
+	seasonLength := 12 / step
+
+	// Фаза — номер интервала длиной step месяцев внутри года
+	positions := make([]int, len(dates))
+	for i, date := range dates {
+		positions[i] = (int(date.Month()) - 1) / step
+	}
+
+	return positions, seasonLength, true
+}
+
+// parsePeriod разбирает период в формате "2006-01-02" или "2006-Q1"
+func parsePeriod(period string) (time.Time, bool) {
+	if date, err := time.Parse("2006-01-02", period); err == nil {
+		return date, true
+	}
+
+	parts := strings.Split(period, "-Q")
+	if len(parts) != 2 {
+		return time.Time{}, false
+	}
+	year, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return time.Time{}, false
+	}
+	quarter, err := strconv.Atoi(parts[1])
+	if err != nil || quarter < 1 || quarter > 4 {
+		return time.Time{}, false
+	}
+	return time.Date(year, time.Month((quarter-1)*3+1), 1, 0, 0, 0, 0, time.UTC), true
+}
+
+// slopePerYear оценивает наклон ряда МНК в единицах за год; periodsPerYear — число
+// наблюдений в году, для сезонного ряда равное длине цикла
+func slopePerYear(series []float64, periodsPerYear int) float64 {
+	n := float64(len(series))
+	if n < 2 {
+		return 0
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i, y := range series {
+		x := float64(i)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator * float64(periodsPerYear)
+}
@@ -3,8 +3,6 @@ package core
 import (
 	"osm_service/internal/domain/model"
 	"sort"
-	"strconv"
-	"strings"
 )
 
 type TemporalAnalyzer struct{}
@@ -33,20 +31,8 @@ func (a *TemporalAnalyzer) Analyze(data []model.HistoricalData, years int) model
 	if len(data) > 0 {
 		// Получаем границы кластера из первого элемента
 		bbox := data[0].BBox // Используем BBox вместо Period
-		parts := strings.Split(bbox, ",")
-		if len(parts) == 4 {
-			minLat, _ := strconv.ParseFloat(parts[0], 64)
-			minLon, _ := strconv.ParseFloat(parts[1], 64)
-			maxLat, _ := strconv.ParseFloat(parts[2], 64)
-			maxLon, _ := strconv.ParseFloat(parts[3], 64)
-
+		if bounds, err := model.ParseBounds(bbox); err == nil {
 			// Рассчитываем площадь кластера
-			bounds := model.Bounds{
-				MinLat: minLat,
-				MinLon: minLon,
-				MaxLat: maxLat,
-				MaxLon: maxLon,
-			}
 			clusterArea := calculateArea(bounds)
 
 			// Рассчитываем плотность как отношение количества объектов к площади кластера
@@ -68,8 +54,36 @@ func (a *TemporalAnalyzer) Analyze(data []model.HistoricalData, years int) model
 		features.NetGrowthRate = float64(netChange) / float64(startCount)
 	}
 
-	// Рассчитываем наклон тренда
-	features.TrendSlope = float64(netChange) / float64(years)
+	// Рассчитываем наклон тренда по фактическому интервалу между первым и последним
+	// снимком: при шаге меньше года период может быть короче года
+	if span := seriesYears(data, years); span > 0 {
+		features.TrendSlope = float64(netChange) / span
+	}
+
+	// Для данных чаще раза в год выделяем сезонность, чтобы летние веранды
+	// и сезонные киоски не искажали тренд
+	features.DeseasonalizedTrendSlope = features.TrendSlope
+	if positions, seasonLength, ok := seasonalPositions(data); ok {
+		series := make([]float64, len(data))
+		for i, item := range data {
+			series[i] = float64(item.TotalObjects)
+		}
+		if decomposition, ok := Decompose(series, positions, seasonLength); ok {
+			features.SeasonalAmplitude = decomposition.Amplitude()
+			features.DeseasonalizedTrendSlope = slopePerYear(decomposition.Deseasonalized(), seasonLength)
+		}
+	}
 
 	return features
 }
+
+// seriesYears возвращает длину ряда в годах между первым и последним периодом.
+// Если периоды не разбираются, используется years.
+func seriesYears(data []model.HistoricalData, years int) float64 {
+	first, okFirst := parsePeriod(data[0].Period)
+	last, okLast := parsePeriod(data[len(data)-1].Period)
+	if !okFirst || !okLast {
+		return float64(years)
+	}
+	return last.Sub(first).Hours() / (24 * 365.25)
+}
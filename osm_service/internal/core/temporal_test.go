@@ -0,0 +1,79 @@
+package core
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"osm_service/internal/domain/model"
+)
+
+func monthlySeries(start time.Time, months int, count func(i int) int) []model.HistoricalData {
+	data := make([]model.HistoricalData, months+1)
+	for i := range data {
+		data[i] = model.HistoricalData{
+			Period:       start.AddDate(0, i, 0).Format("2006-01-02"),
+			TotalObjects: count(i),
+		}
+	}
+	return data
+}
+
+func TestTemporalAnalyzeShortPeriod(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Полгода ежемесячных снимков: years = 0, наклон должен считаться по длине ряда
+	data := monthlySeries(start, 6, func(i int) int { return 10 + i })
+
+	analyzer := TemporalAnalyzer{}
+	features := analyzer.Analyze(data, 0)
+
+	for name, value := range map[string]float64{
+		"trend_slope":                features.TrendSlope,
+		"deseasonalized_trend_slope": features.DeseasonalizedTrendSlope,
+	} {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			t.Fatalf("%s = %v, want a finite value", name, value)
+		}
+	}
+	// 6 объектов за полгода — около 12 в год
+	if math.Abs(features.TrendSlope-12) > 0.2 {
+		t.Errorf("TrendSlope = %v, want about 12 per year", features.TrendSlope)
+	}
+	if _, err := json.Marshal(features); err != nil {
+		t.Errorf("features do not marshal: %v", err)
+	}
+}
+
+func TestTemporalAnalyzeSingleDate(t *testing.T) {
+	data := []model.HistoricalData{
+		{Period: "2023-01-01", TotalObjects: 10},
+		{Period: "2023-01-01", TotalObjects: 12},
+	}
+	analyzer := TemporalAnalyzer{}
+	if slope := analyzer.Analyze(data, 0).TrendSlope; slope != 0 {
+		t.Errorf("TrendSlope over a zero span = %v, want 0", slope)
+	}
+}
+
+func TestTemporalAnalyzeSeasonalSteps(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, step := range []int{1, 2, 3, 4, 6} {
+		var data []model.HistoricalData
+		for i := 0; i <= 48/step; i++ {
+			date := start.AddDate(0, i*step, 0)
+			// Рост 12 объектов в год плюс летний пик
+			count := 100 + i*step
+			if date.Month() >= 6 && date.Month() <= 8 {
+				count += 20
+			}
+			data = append(data, model.HistoricalData{Period: date.Format("2006-01-02"), TotalObjects: count})
+		}
+
+		analyzer := TemporalAnalyzer{}
+		features := analyzer.Analyze(data, 4)
+		if math.Abs(features.DeseasonalizedTrendSlope-12) > 3 {
+			t.Errorf("step %d: DeseasonalizedTrendSlope = %v, want about 12", step, features.DeseasonalizedTrendSlope)
+		}
+	}
+}
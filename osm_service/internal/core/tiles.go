@@ -0,0 +1,36 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"osm_service/internal/domain/model"
+)
+
+// CommercialObjects возвращает объекты категории в области без расстояний до дорог
+// и метро — только координаты и теги, например для точек на карте
+func (s *PredictionService) CommercialObjects(ctx context.Context, bounds model.Bounds, shopType string) ([]model.OSMElement, error) {
+	bbox := fmt.Sprintf("%f,%f,%f,%f", bounds.MinLat, bounds.MinLon, bounds.MaxLat, bounds.MaxLon)
+	elements, err := s.overpassRepo.GetCommercialData(ctx, bbox, shopType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commercial data: %w", err)
+	}
+	return elements, nil
+}
+
+// ClusterActivity предсказывает уровень активности кластера по его признакам
+// экспортированной моделью. Без экспортированных моделей или подходящей модели
+// возвращает false: ML сервис для каждой ячейки карты не запрашивается.
+func (s *PredictionService) ClusterActivity(shopType string, bounds model.Bounds, features model.FeatureVector) (float64, bool) {
+	if s.modelStore == nil {
+		return 0, false
+	}
+	exported, err := s.modelStore.Find(shopType, "", bounds)
+	if err != nil {
+		return 0, false
+	}
+	activity, err := exported.Evaluate(features)
+	if err != nil {
+		return 0, false
+	}
+	return activity, true
+}
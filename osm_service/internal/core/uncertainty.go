@@ -0,0 +1,173 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"osm_service/internal/domain/model"
+	"time"
+)
+
+// intervalZ — квантиль нормального распределения для уровня forecastLevel (95%)
+const intervalZ = 1.96
+
+// Пороги, ниже которых данные считаются слабой опорой для предсказания
+const (
+	minSupportedObjects = 20 // при таком числе объектов вклад в оценку качества ~0.63
+	minHistoryShare     = 0.75
+)
+
+// addUncertainty дополняет предсказание интервалами и оценкой качества данных.
+// Интервалы, пришедшие от модели, сохраняются; недостающие оцениваются по истории
+// или, если предсказание построено прогнозом ряда, по интервалу прогноза.
+func addUncertainty(
+	prediction *model.Prediction,
+	historical []model.HistoricalData,
+	features *model.FeatureVector,
+	startDate time.Time,
+	endDate time.Time,
+	fromForecast bool,
+) {
+	if prediction.Intervals == nil {
+		prediction.Intervals = &model.PredictionIntervals{}
+	}
+	intervals := prediction.Intervals
+	intervals.Level = forecastLevel
+
+	newRates, closureRates := historicalRates(historical)
+	newSD := stdDev(newRates)
+	closureSD := stdDev(closureRates)
+
+	if intervals.ActivityLevel == nil {
+		// activity_level линейно зависит от темпов открытий (0.4) и закрытий (0.3),
+		// поэтому его разброс оценивается через разброс темпов по годам
+		half := intervalZ * math.Sqrt(0.16*newSD*newSD+0.09*closureSD*closureSD)
+		intervals.ActivityLevel = &model.Interval{
+			Lower:  prediction.ActivityLevel - half,
+			Upper:  prediction.ActivityLevel + half,
+			Method: model.IntervalRateVariance,
+		}
+	}
+
+	if fromForecast && prediction.Forecast != nil && len(prediction.Forecast.Points) > 0 && len(historical) > 0 {
+		// Прогноз ряда дает интервал общего количества объектов; прирост и сокращение —
+		// это его положительная и отрицательная части относительно последнего наблюдения
+		point := prediction.Forecast.Points[len(prediction.Forecast.Points)-1]
+		last := float64(historical[len(historical)-1].TotalObjects)
+		intervals.PredictedNew = &model.Interval{
+			Lower:  math.Max(point.Lower-last, 0),
+			Upper:  math.Max(point.Upper-last, 0),
+			Method: model.IntervalForecast,
+		}
+		intervals.PredictedClosed = &model.Interval{
+			Lower:  math.Max(last-point.Upper, 0),
+			Upper:  math.Max(last-point.Lower, 0),
+			Method: model.IntervalForecast,
+		}
+	} else {
+		base := prediction.TotalObjects
+		if len(historical) > 0 {
+			base = historical[len(historical)-1].TotalObjects
+		}
+		if intervals.PredictedNew == nil {
+			intervals.PredictedNew = countInterval(prediction.PredictedNew, base, newSD)
+		}
+		if intervals.PredictedClosed == nil {
+			intervals.PredictedClosed = countInterval(prediction.PredictedClosed, base, closureSD)
+		}
+	}
+
+	quality := dataQuality(historical, features, startDate, endDate)
+	prediction.DataQuality = &quality
+}
+
+// countInterval оценивает интервал числа событий: пуассоновский шум самого счета
+// плюс изменчивость темпа от года к году
+func countInterval(predicted, base int, rateSD float64) *model.Interval {
+	spread := float64(base) * rateSD
+	half := intervalZ * math.Sqrt(float64(predicted)+spread*spread)
+	return &model.Interval{
+		Lower:  math.Max(float64(predicted)-half, 0),
+		Upper:  float64(predicted) + half,
+		Method: model.IntervalRateVariance,
+	}
+}
+
+// historicalRates возвращает темпы открытий и закрытий между соседними снимками
+func historicalRates(historical []model.HistoricalData) (newRates, closureRates []float64) {
+	for i := 1; i < len(historical); i++ {
+		prev := historical[i-1].TotalObjects
+		if prev == 0 {
+			continue
+		}
+		newRates = append(newRates, float64(historical[i].NewObjects)/float64(prev))
+		closureRates = append(closureRates, float64(historical[i].ClosedObjects)/float64(prev))
+	}
+	return newRates, closureRates
+}
+
+// stdDev — выборочное стандартное отклонение; для одного значения разброс неизвестен и равен 0
+func stdDev(values []float64) float64 {
+	return math.Sqrt(variance(values))
+}
+
+// dataQuality оценивает, насколько данные OSM подкрепляют предсказание:
+// полнота истории (40%), число объектов (40%) и полнота признаков (20%)
+func dataQuality(
+	historical []model.HistoricalData,
+	features *model.FeatureVector,
+	startDate time.Time,
+	endDate time.Time,
+) model.DataQuality {
+	quality := model.DataQuality{
+		Snapshots:         len(historical),
+		ExpectedSnapshots: endDate.Year() - startDate.Year() + 1,
+	}
+	if quality.ExpectedSnapshots < 1 {
+		quality.ExpectedSnapshots = 1
+	}
+
+	history := math.Min(float64(quality.Snapshots)/float64(quality.ExpectedSnapshots), 1)
+	if history < minHistoryShare {
+		quality.Warnings = append(quality.Warnings,
+			fmt.Sprintf("only %d of %d yearly snapshots are available", quality.Snapshots, quality.ExpectedSnapshots))
+	}
+
+	if len(historical) > 0 {
+		quality.ObservedObjects = historical[len(historical)-1].TotalObjects
+	}
+	objects := 1 - math.Exp(-float64(quality.ObservedObjects)/minSupportedObjects)
+	if quality.ObservedObjects < minSupportedObjects {
+		quality.Warnings = append(quality.Warnings,
+			fmt.Sprintf("only %d objects observed in the area", quality.ObservedObjects))
+	}
+
+	var completeness float64
+	if features == nil {
+		quality.Warnings = append(quality.Warnings, "features are unavailable, prediction is based on the object count series")
+	} else {
+		checks := []bool{
+			features.AvgArea > 0,
+			validDistance(features.AvgDistToPrimary),
+			validDistance(features.AvgDistToSubway),
+		}
+		var passed int
+		for _, ok := range checks {
+			if ok {
+				passed++
+			}
+		}
+		completeness = float64(passed) / float64(len(checks))
+		quality.FeaturesComplete = passed == len(checks)
+		if !quality.FeaturesComplete {
+			quality.Warnings = append(quality.Warnings, "some spatial features are missing (building area, roads or subway)")
+		}
+	}
+
+	quality.Score = 0.4*history + 0.4*objects + 0.2*completeness
+	return quality
+}
+
+// validDistance отсекает нулевые расстояния (нет опорных объектов) и заглушку math.MaxFloat64
+func validDistance(distance float64) bool {
+	return distance > 0 && distance < math.MaxFloat64/2
+}
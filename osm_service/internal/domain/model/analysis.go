@@ -0,0 +1,88 @@
+package model
+
+// ChangePoint описывает момент, в который ряд количества объектов сменил уровень
+type ChangePoint struct {
+	Index        int     `json:"index"`         // индекс первого периода нового сегмента
+	Period       string  `json:"period"`        // период, с которого начинается новый сегмент
+	MeanBefore   float64 `json:"mean_before"`   // среднее количество объектов до точки
+	MeanAfter    float64 `json:"mean_after"`    // среднее количество объектов после точки
+	Magnitude    float64 `json:"magnitude"`     // абсолютное изменение уровня
+	RelativeJump float64 `json:"relative_jump"` // изменение уровня относительно MeanBefore
+
+	Infrastructure *InfrastructureChange `json:"infrastructure,omitempty"`
+}
+
+// InfrastructureChange содержит изменения инфраструктуры вокруг точки перелома
+type InfrastructureChange struct {
+	SubwayStationsBefore int     `json:"subway_stations_before"`
+	SubwayStationsAfter  int     `json:"subway_stations_after"`
+	NewSubwayStations    []int64 `json:"new_subway_stations"`
+}
+
+// Forecast содержит точечный прогноз ряда и интервалы предсказания
+type Forecast struct {
+	Method string          `json:"method"`
+	Level  float64         `json:"level"` // доверительный уровень интервалов, например 0.95
+	Points []ForecastPoint `json:"points"`
+}
+
+// ForecastPoint — прогноз на Step периодов вперед
+type ForecastPoint struct {
+	Step  int     `json:"step"`
+	Value float64 `json:"value"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// Типы событий, которыми связываются объекты двух снимков OSM
+const (
+	EventOpening         = "opening"          // объект появился
+	EventClosure         = "closure"          // объект исчез
+	EventRename          = "rename"           // изменилось название
+	EventRebrand         = "rebrand"          // изменился бренд
+	EventCategoryChange  = "category_change"  // изменилась категория (shop/amenity)
+	EventGeometryUpgrade = "geometry_upgrade" // точка заменена контуром здания или наоборот
+)
+
+// ElementEvent описывает, что произошло с объектом между двумя снимками
+type ElementEvent struct {
+	Kind       string      `json:"kind"`
+	Before     *OSMElement `json:"before,omitempty"`
+	After      *OSMElement `json:"after,omitempty"`
+	Distance   float64     `json:"distance"`   // расстояние между объектами в метрах
+	Similarity float64     `json:"similarity"` // сходство тегов от 0 до 1
+}
+
+// Типы инфраструктурных событий
+const (
+	InfrastructureSubwayStation = "subway_station"
+	InfrastructurePrimaryRoad   = "primary_road"
+)
+
+// InfrastructureEvent — новый объект инфраструктуры, появившийся за период
+type InfrastructureEvent struct {
+	Kind string  `json:"kind"`
+	ID   int64   `json:"id"`
+	Name string  `json:"name,omitempty"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+}
+
+// ImpactEstimate содержит оценку влияния события на коммерческую активность:
+// сравнение до/после в кольце вокруг события и разность разностей с контрольной зоной
+type ImpactEstimate struct {
+	Event InfrastructureEvent `json:"event"`
+
+	TreatedBefore int `json:"treated_before"` // объектов в зоне влияния до
+	TreatedAfter  int `json:"treated_after"`  // объектов в зоне влияния после
+	ControlBefore int `json:"control_before"` // объектов в контрольной зоне до
+	ControlAfter  int `json:"control_after"`  // объектов в контрольной зоне после
+
+	TreatedDensityChange float64 `json:"treated_density_change"` // изменение плотности, объектов/км²
+	ControlDensityChange float64 `json:"control_density_change"` // изменение плотности, объектов/км²
+	DiffInDiff           float64 `json:"diff_in_diff"`           // разность изменений плотности, объектов/км²
+
+	TreatedGrowth      float64 `json:"treated_growth"`        // относительный рост в зоне влияния
+	ControlGrowth      float64 `json:"control_growth"`        // относительный рост в контрольной зоне
+	RelativeDiffInDiff float64 `json:"relative_diff_in_diff"` // разность относительных ростов
+}
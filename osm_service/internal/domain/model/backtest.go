@@ -0,0 +1,67 @@
+package model
+
+import "time"
+
+// BacktestOptions задает проверку предсказаний на истории
+type BacktestOptions struct {
+	Bounds       Bounds
+	Categories   []string  // типы объектов (shop_type)
+	ClusterSize  float64   // размер кластера в км
+	Cutoff       time.Time // признаки строятся только по данным до этой даты
+	TrainYears   int       // длина периода признаков до Cutoff
+	HorizonYears int       // сколько лет после Cutoff сверяется с фактом
+}
+
+// ErrorMetrics — ошибки предсказания одной величины. MAPE считается только
+// по наблюдениям с ненулевым фактом и равен nil, если таких нет.
+type ErrorMetrics struct {
+	Count int      `json:"count"`
+	MAE   float64  `json:"mae"`
+	RMSE  float64  `json:"rmse"`
+	MAPE  *float64 `json:"mape,omitempty"`
+}
+
+// BacktestMetrics — ошибки по всем проверяемым величинам
+type BacktestMetrics struct {
+	ActivityLevel   ErrorMetrics `json:"activity_level"`
+	PredictedNew    ErrorMetrics `json:"predicted_new"`
+	PredictedClosed ErrorMetrics `json:"predicted_closed"`
+}
+
+// BacktestObservation — предсказание на год после Cutoff и то, что произошло на самом деле
+type BacktestObservation struct {
+	Year            int     `json:"year"`
+	ModelUsed       string  `json:"model_used"`
+	ActivityLevel   float64 `json:"activity_level"`
+	PredictedNew    int     `json:"predicted_new"`
+	PredictedClosed int     `json:"predicted_closed"`
+	ActualActivity  float64 `json:"actual_activity_level"`
+	ActualNew       int     `json:"actual_new"`
+	ActualClosed    int     `json:"actual_closed"`
+}
+
+// ClusterBacktest — результат проверки для одного кластера и категории
+type ClusterBacktest struct {
+	Index        int                   `json:"index"`
+	BBox         string                `json:"bbox"`
+	Category     string                `json:"category"`
+	Observations []BacktestObservation `json:"observations,omitempty"`
+	Metrics      *BacktestMetrics      `json:"metrics,omitempty"`
+	Error        string                `json:"error,omitempty"`
+}
+
+// CategoryBacktest — ошибки по всем кластерам категории
+type CategoryBacktest struct {
+	Category string          `json:"category"`
+	Clusters int             `json:"clusters"`
+	Failed   int             `json:"failed"`
+	Metrics  BacktestMetrics `json:"metrics"`
+}
+
+// BacktestResult — отчет о проверке предсказаний
+type BacktestResult struct {
+	Cutoff        string             `json:"cutoff"`
+	EvaluationEnd string             `json:"evaluation_end"`
+	Clusters      []ClusterBacktest  `json:"clusters"`
+	Categories    []CategoryBacktest `json:"categories"`
+}
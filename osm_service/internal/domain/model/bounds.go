@@ -0,0 +1,44 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidBBox — строка bbox не разбирается или задает некорректную область
+var ErrInvalidBBox = errors.New("invalid bbox")
+
+// ParseBounds разбирает область в формате "minLat,minLon,maxLat,maxLon" и проверяет,
+// что координаты в допустимых диапазонах, а минимум каждой оси меньше максимума.
+// Все разборы bbox в сервисе идут через эту функцию.
+func ParseBounds(bbox string) (Bounds, error) {
+	parts := strings.Split(bbox, ",")
+	if len(parts) != 4 {
+		return Bounds{}, fmt.Errorf("%w: expected \"minLat,minLon,maxLat,maxLon\", got %q", ErrInvalidBBox, bbox)
+	}
+
+	names := [4]string{"minLat", "minLon", "maxLat", "maxLon"}
+	var values [4]float64
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			return Bounds{}, fmt.Errorf("%w: invalid %s %q", ErrInvalidBBox, names[i], part)
+		}
+		values[i] = value
+	}
+
+	bounds := Bounds{MinLat: values[0], MinLon: values[1], MaxLat: values[2], MaxLon: values[3]}
+	switch {
+	case bounds.MinLat < -90 || bounds.MinLat > 90 || bounds.MaxLat < -90 || bounds.MaxLat > 90:
+		return Bounds{}, fmt.Errorf("%w: latitude out of range [-90, 90]", ErrInvalidBBox)
+	case bounds.MinLon < -180 || bounds.MinLon > 180 || bounds.MaxLon < -180 || bounds.MaxLon > 180:
+		return Bounds{}, fmt.Errorf("%w: longitude out of range [-180, 180]", ErrInvalidBBox)
+	case bounds.MinLat >= bounds.MaxLat || bounds.MinLon >= bounds.MaxLon:
+		return Bounds{}, fmt.Errorf("%w: minLat must be less than maxLat and minLon less than maxLon", ErrInvalidBBox)
+	}
+	return bounds, nil
+}
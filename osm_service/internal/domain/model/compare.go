@@ -0,0 +1,14 @@
+package model
+
+// ComparedArea — признаки, предсказание и место области при сравнении областей
+type ComparedArea struct {
+	ID         string            `json:"id,omitempty"`
+	BBox       string            `json:"bbox"`
+	Spatial    *SpatialFeatures  `json:"spatial,omitempty"`
+	Temporal   *TemporalFeatures `json:"temporal,omitempty"`
+	Prediction *Prediction       `json:"prediction,omitempty"`
+	Rank       int               `json:"rank,omitempty"`     // место по activity_level, 1 — самая высокая активность
+	Fallback   bool              `json:"fallback,omitempty"` // предсказание по прогнозу ряда, область не ранжируется
+
+	Err error `json:"-"` // ошибка предсказания области
+}
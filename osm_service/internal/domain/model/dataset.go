@@ -0,0 +1,37 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrCheckpointNotFound возвращается, если для задачи нет сохраненного состояния
+var ErrCheckpointNotFound = errors.New("dataset checkpoint not found")
+
+// DatasetRequest описывает построение датасета для обучения
+type DatasetRequest struct {
+	BBox           string    `json:"bbox"`
+	ShopType       string    `json:"shop_type"`
+	ClusterSize    float64   `json:"cluster_size"` // размер кластера в км
+	StartDate      time.Time `json:"start_date"`
+	EndDate        time.Time `json:"end_date"`
+	SamplingMonths int       `json:"sampling_months"` // шаг снимков в месяцах
+}
+
+// Dataset — файл датасета, который читает train_models.py
+type Dataset struct {
+	Clusters []DatasetCluster `json:"clusters"`
+}
+
+// DatasetCluster — признаки кластера по годам
+type DatasetCluster struct {
+	Index int           `json:"index"`
+	Bbox  string        `json:"bbox"`
+	Data  []DatasetYear `json:"data"`
+}
+
+// DatasetYear — строка признаков кластера за год
+type DatasetYear struct {
+	Year int             `json:"year"`
+	Data []FeatureVector `json:"data"`
+}
@@ -0,0 +1,75 @@
+package model
+
+import "time"
+
+// Режимы выкатки новой модели
+const (
+	RolloutShadow = "shadow" // кандидат считается параллельно, ответ всегда от текущей модели
+	RolloutCanary = "canary" // часть запросов обслуживает кандидат
+)
+
+// Роли модели в записи журнала предсказаний
+const (
+	RolePrimary = "primary" // текущая модель, ответ отдан клиенту
+	RoleCanary  = "canary"  // кандидат, ответ отдан клиенту
+	RoleShadow  = "shadow"  // кандидат, ответ только сохранен
+)
+
+// Rollout описывает выкатку модели-кандидата из реестра
+type Rollout struct {
+	Mode      string  `json:"mode"`
+	Candidate string  `json:"candidate"` // имя модели в реестре
+	Percent   float64 `json:"percent"`   // доля запросов (0–100), только для canary
+}
+
+// PredictionRecord — предсказание, сохраненное для сравнения моделей
+type PredictionRecord struct {
+	RequestID       string    `json:"request_id"` // общий для основного и теневого предсказания
+	Role            string    `json:"role"`
+	ModelName       string    `json:"model_name"`
+	BBox            string    `json:"bbox"`
+	ShopType        string    `json:"shop_type"`
+	PredictionYear  string    `json:"prediction_year"`
+	ActivityLevel   float64   `json:"activity_level"`
+	PredictedNew    int       `json:"predicted_new"`
+	PredictedClosed int       `json:"predicted_closed"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// GroundTruth — фактические изменения в области за год, с которыми сверяются предсказания
+type GroundTruth struct {
+	BBox         string `json:"bbox"`
+	ShopType     string `json:"shop_type"`
+	Year         string `json:"year"`
+	ActualNew    int    `json:"actual_new"`
+	ActualClosed int    `json:"actual_closed"`
+	TotalObjects int    `json:"total_objects"`
+}
+
+// ModelAccuracy — сводка по предсказаниям одной модели в одной роли
+type ModelAccuracy struct {
+	ModelName   string   `json:"model_name"`
+	Role        string   `json:"role"`
+	Predictions int      `json:"predictions"`
+	Evaluated   int      `json:"evaluated"` // предсказаний, для которых известны факты
+	MAENew      *float64 `json:"mae_new,omitempty"`
+	MAEClosed   *float64 `json:"mae_closed,omitempty"`
+	RMSENew     *float64 `json:"rmse_new,omitempty"`
+}
+
+// ModelDivergence — расхождение теневой модели с основной на одних и тех же запросах
+type ModelDivergence struct {
+	PrimaryModel         string  `json:"primary_model"`
+	ShadowModel          string  `json:"shadow_model"`
+	Pairs                int     `json:"pairs"`
+	MeanActivityDiff     float64 `json:"mean_activity_diff"`
+	MaxActivityDiff      float64 `json:"max_activity_diff"`
+	MeanPredictedNewDiff float64 `json:"mean_predicted_new_diff"`
+}
+
+// ModelComparison — ответ эндпоинта сравнения моделей
+type ModelComparison struct {
+	Rollout    *Rollout          `json:"rollout,omitempty"`
+	Accuracy   []ModelAccuracy   `json:"accuracy"`
+	Divergence []ModelDivergence `json:"divergence"`
+}
@@ -0,0 +1,24 @@
+package model
+
+// Методы расчета вкладов признаков
+const (
+	ExplanationLinear   = "linear"    // коэффициент × нормализованное значение признака
+	ExplanationTreePath = "tree_path" // изменение значения узлов вдоль пути в деревьях (Saabas)
+)
+
+// FeatureContribution — вклад признака в activity_level
+type FeatureContribution struct {
+	Feature      string  `json:"feature"`
+	Value        float64 `json:"value"`        // значение признака до нормализации
+	Contribution float64 `json:"contribution"` // сдвиг предсказания относительно BaseValue
+}
+
+// Explanation объясняет предсказание: BaseValue плюс сумма вкладов равны activity_level модели
+type Explanation struct {
+	Model         string                `json:"model,omitempty"`
+	Method        string                `json:"method,omitempty"`
+	BaseValue     float64               `json:"base_value"`
+	Contributions []FeatureContribution `json:"contributions,omitempty"` // по убыванию абсолютного вклада
+	Features      *FeatureVector        `json:"features,omitempty"`      // признаки, переданные модели
+	Note          string                `json:"note,omitempty"`          // почему вклады не посчитаны или посчитаны другой моделью
+}
@@ -1,12 +1,62 @@
 package model
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrMLServiceUnavailable означает, что ML сервис не отвечает или временно отключен
+// (сетевые ошибки, таймауты, 502/503/504, разомкнутый circuit breaker)
+var ErrMLServiceUnavailable = errors.New("ML service is unavailable")
+
 // MLClient определяет интерфейс для взаимодействия с ML сервисом
 type MLClient interface {
 	// GetAvailableModels возвращает список доступных моделей
-	GetAvailableModels() ([]ModelInfo, error)
+	GetAvailableModels(ctx context.Context) ([]ModelInfo, error)
 
 	// GetPrediction получает предсказание для указанной области
-	GetPrediction(bbox string, modelName string, predictionYear string) (*Prediction, error)
+	GetPrediction(ctx context.Context, query PredictionQuery) (*Prediction, error)
+}
+
+// PredictionQuery описывает запрос предсказания к ML сервису
+type PredictionQuery struct {
+	BBox           string
+	ShopType       string
+	PredictionYear string
+	TrainPeriod    string // период обучения модели в формате "2019-2023"
+
+	// Features — признаки области, посчитанные так же, как при построении датасета
+	Features *FeatureVector
+
+	// Model — имя конкретной модели; если пусто, модель выбирается по области
+	Model string
+}
+
+// MLServiceError — ошибка, которую вернул ML сервис, с текстом из тела ответа
+type MLServiceError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *MLServiceError) Error() string {
+	return fmt.Sprintf("ML service returned %d: %s", e.StatusCode, e.Message)
+}
+
+// Is позволяет проверять ответы шлюза через errors.Is(err, ErrMLServiceUnavailable),
+// а отсутствие модели в ML сервисе (404) — через errors.Is(err, ErrModelNotFound)
+func (e *MLServiceError) Is(target error) bool {
+	switch target {
+	case ErrMLServiceUnavailable:
+		switch e.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	case ErrModelNotFound:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
 }
 
 // ModelInfo содержит информацию о модели
@@ -31,6 +81,24 @@ type Prediction struct {
 	TotalObjects    int       `json:"total_objects"`
 	ModelUsed       string    `json:"model_used"`
 	Hotspots        []Hotspot `json:"hotspots"`
+
+	// Forecast заполняется, если предсказание построено (или сверено) прогнозом ряда в Go
+	Forecast *Forecast `json:"forecast,omitempty"`
+
+	// Fallback — модель недоступна, и предсказание построено прогнозом ряда вместо нее
+	Fallback bool `json:"fallback,omitempty"`
+
+	// Intervals — диапазоны activity_level, predicted_new и predicted_closed
+	Intervals *PredictionIntervals `json:"intervals,omitempty"`
+
+	// DataQuality — насколько данные OSM подкрепляют предсказание
+	DataQuality *DataQuality `json:"data_quality,omitempty"`
+
+	// Explanation заполняется по запросу explain=true
+	Explanation *Explanation `json:"explanation,omitempty"`
+
+	// Ensemble — модели, из которых собрано ансамблевое предсказание
+	Ensemble []EnsembleMember `json:"ensemble,omitempty"`
 }
 
 // Hotspot представляет точку интереса
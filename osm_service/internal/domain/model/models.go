@@ -27,11 +27,11 @@ type PredictionResult struct {
 }
 
 type SpatialFeatures struct {
-	TotalObjects     int
-	AvgArea          float64
-	SubwayStations   int
-	AvgDistToSubway  float64
-	AvgDistToPrimary float64
+	TotalObjects     int     `json:"total_objects"`
+	AvgArea          float64 `json:"avg_area"`
+	SubwayStations   int     `json:"subway_stations"`
+	AvgDistToSubway  float64 `json:"avg_dist_to_subway"`
+	AvgDistToPrimary float64 `json:"avg_dist_to_primary"`
 }
 
 type HistoricalData struct {
@@ -40,13 +40,40 @@ type HistoricalData struct {
 	TotalObjects  int
 	NewObjects    int
 	ClosedObjects int
+	// Events — количество событий по типам (открытия, переименования, ребрендинг и т.д.)
+	Events map[string]int
 }
 
 type TemporalFeatures struct {
-	YearsAnalyzed int
-	ObjectDensity float64 // объектов/год
-	NewObjectRate float64 // доля новых объектов
-	ClosureRate   float64 // доля закрытых объектов
-	NetGrowthRate float64 // чистая скорость роста
-	TrendSlope    float64 // наклон тренда
+	YearsAnalyzed int     `json:"years_analyzed"`
+	ObjectDensity float64 `json:"object_density"`  // объектов/год
+	NewObjectRate float64 `json:"new_object_rate"` // доля новых объектов
+	ClosureRate   float64 `json:"closure_rate"`    // доля закрытых объектов
+	NetGrowthRate float64 `json:"net_growth_rate"` // чистая скорость роста
+	TrendSlope    float64 `json:"trend_slope"`     // наклон тренда
+
+	// Для данных чаще раза в год
+	SeasonalAmplitude        float64 `json:"seasonal_amplitude"`         // размах сезонных колебаний количества объектов
+	DeseasonalizedTrendSlope float64 `json:"deseasonalized_trend_slope"` // наклон тренда без сезонности, объектов/год
+}
+
+// FeatureVector — признаки кластера за год. Используется и в датасете для обучения,
+// и в запросе предсказания, поэтому набор полей должен совпадать с FEATURE_COLUMNS в ML сервисе.
+type FeatureVector struct {
+	AvgArea          float64 `json:"avg_area"`
+	AvgDistToPrimary float64 `json:"avg_dist_to_primary"`
+	AvgDistToSubway  float64 `json:"avg_dist_to_subway"`
+	ClosureRate      float64 `json:"closure_rate"`
+	NewObjectRate    float64 `json:"new_object_rate"`
+	ObjectDensity    float64 `json:"object_density"`
+	TotalObjects     int     `json:"total_objects"`
+
+	DeseasonalizedTrend float64 `json:"deseasonalized_trend"`
+	SeasonalAmplitude   float64 `json:"seasonal_amplitude"`
+}
+
+// YearFeatures — признаки кластера за конкретный год
+type YearFeatures struct {
+	Year     int
+	Features FeatureVector
 }
@@ -10,6 +10,7 @@ type OSMElement struct {
 	Area          float64           `json:"area"`            // Площадь объекта в квадратных метрах
 	DistToPrimary float64           `json:"dist_to_primary"` // Расстояние до главной дороги в метрах
 	DistToSubway  float64           `json:"dist_to_subway"`  // Расстояние до метро в метрах
+	NodeIDs       []int64           `json:"-"`               // Узлы линии или контура (только для way)
 }
 
 type Tags struct {
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// FeatureSchemaVersion — версия набора признаков FeatureVector.
+// Увеличивается при добавлении или изменении признаков датасета.
+const FeatureSchemaVersion = "1.1"
+
+// DefaultSamplingMonths — шаг снимков по умолчанию: один снимок в год
+const DefaultSamplingMonths = 12
+
+// Статусы модели в реестре
+const (
+	ModelStatusCandidate = "candidate" // обучена, но не используется для предсказаний
+	ModelStatusActive    = "active"    // используется для предсказаний
+	ModelStatusRetired   = "retired"   // выведена из эксплуатации
+)
+
+// ErrModelNotFound возвращается, если подходящей модели нет
+var ErrModelNotFound = errors.New("model not found")
+
+// ErrModelExists возвращается при регистрации модели с уже занятым именем
+var ErrModelExists = errors.New("model already exists")
+
+// RegisteredModel — запись реестра моделей
+type RegisteredModel struct {
+	ID                   int64        `json:"id"`
+	Name                 string       `json:"name"`
+	Category             string       `json:"category"` // тип объектов (shop_type)
+	TrainStart           time.Time    `json:"train_start"`
+	TrainEnd             time.Time    `json:"train_end"`
+	Coverage             string       `json:"coverage"` // область обучения "minLat,minLon,maxLat,maxLon"
+	FeatureSchemaVersion string       `json:"feature_schema_version"`
+	SamplingMonths       int          `json:"sampling_months"` // шаг снимков датасета обучения в месяцах
+	Metrics              ModelMetrics `json:"metrics"`
+	Status               string       `json:"status"`
+	CreatedAt            time.Time    `json:"created_at"`
+}
+
+// TrainPeriod возвращает период обучения в формате ML сервиса ("2019-2023")
+func (m RegisteredModel) TrainPeriod() string {
+	return m.TrainStart.Format("2006") + "-" + m.TrainEnd.Format("2006")
+}
+
+// SamplingStep возвращает шаг снимков, с которым считались признаки обучения.
+// Признаки для предсказания нужно считать с тем же шагом: сезонные признаки
+// годового и помесячного ряда несопоставимы.
+func (m RegisteredModel) SamplingStep() int {
+	if m.SamplingMonths <= 0 {
+		return DefaultSamplingMonths
+	}
+	return m.SamplingMonths
+}
+
+// ModelMetrics — метрики валидации модели
+type ModelMetrics struct {
+	MSE  float64 `json:"mse"`
+	RMSE float64 `json:"rmse"`
+	R2   float64 `json:"r2"`
+}
+
+// ModelFilter задает условия выборки моделей из реестра; пустые поля не фильтруют
+type ModelFilter struct {
+	Category string
+	Status   string
+}
+
+// EnsembleMember — вклад одной модели в ансамблевое предсказание
+type EnsembleMember struct {
+	Model           string  `json:"model"`
+	TrainPeriod     string  `json:"train_period"`
+	Overlap         float64 `json:"overlap"`       // доля запрошенной области, покрытая моделью
+	MetricWeight    float64 `json:"metric_weight"` // 1/MSE валидации; без метрик — медиана весов других моделей
+	Weight          float64 `json:"weight"`        // итоговый нормированный вес
+	ActivityLevel   float64 `json:"activity_level"`
+	PredictedNew    int     `json:"predicted_new"`
+	PredictedClosed int     `json:"predicted_closed"`
+	Error           string  `json:"error,omitempty"`
+}
@@ -0,0 +1,34 @@
+package model
+
+// Источники интервалов предсказания
+const (
+	IntervalModelTrees   = "model_trees"   // разброс деревьев случайного леса
+	IntervalForecast     = "forecast"      // интервал прогноза ряда в Go
+	IntervalRateVariance = "rate_variance" // изменчивость темпов открытий и закрытий по истории
+)
+
+// Interval — диапазон значения с заданным доверительным уровнем
+type Interval struct {
+	Lower  float64 `json:"lower"`
+	Upper  float64 `json:"upper"`
+	Method string  `json:"method"`
+}
+
+// PredictionIntervals — интервалы для основных величин предсказания
+type PredictionIntervals struct {
+	Level           float64   `json:"level"`
+	ActivityLevel   *Interval `json:"activity_level,omitempty"`
+	PredictedNew    *Interval `json:"predicted_new,omitempty"`
+	PredictedClosed *Interval `json:"predicted_closed,omitempty"`
+}
+
+// DataQuality показывает, насколько данные OSM подкрепляют предсказание.
+// Score от 0 до 1 складывается из полноты истории, числа объектов и полноты признаков.
+type DataQuality struct {
+	Score             float64  `json:"score"`
+	Snapshots         int      `json:"snapshots"`
+	ExpectedSnapshots int      `json:"expected_snapshots"`
+	ObservedObjects   int      `json:"observed_objects"`
+	FeaturesComplete  bool     `json:"features_complete"`
+	Warnings          []string `json:"warnings,omitempty"`
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"osm_service/internal/domain/model"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type DatasetCheckpoints interface {
+	SaveRequest(ctx context.Context, jobID string, req model.DatasetRequest) error
+	LoadRequest(ctx context.Context, jobID string) (model.DatasetRequest, error)
+	SaveCluster(ctx context.Context, jobID string, cluster model.DatasetCluster) error
+	LoadClusters(ctx context.Context, jobID string) (map[int]model.DatasetCluster, error)
+	Remove(ctx context.Context, jobID string) error
+}
+
+// FileDatasetCheckpoints хранит состояние построения датасета в каталоге на диске:
+// {dir}/{jobID}/request.json и по файлу на каждый готовый кластер
+type FileDatasetCheckpoints struct {
+	dir string
+}
+
+func NewFileDatasetCheckpoints(dir string) *FileDatasetCheckpoints {
+	return &FileDatasetCheckpoints{dir: dir}
+}
+
+// jobIDPattern не дает идентификатору задачи выйти за пределы каталога чекпоинтов
+var jobIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func (c *FileDatasetCheckpoints) SaveRequest(ctx context.Context, jobID string, req model.DatasetRequest) error {
+	dir, err := c.jobDir(jobID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	return writeJSONAtomic(filepath.Join(dir, "request.json"), req)
+}
+
+func (c *FileDatasetCheckpoints) LoadRequest(ctx context.Context, jobID string) (model.DatasetRequest, error) {
+	var req model.DatasetRequest
+
+	dir, err := c.jobDir(jobID)
+	if err != nil {
+		return req, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "request.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return req, fmt.Errorf("%w for job %s", model.ErrCheckpointNotFound, jobID)
+	}
+	if err != nil {
+		return req, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return req, fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	return req, nil
+}
+
+func (c *FileDatasetCheckpoints) SaveCluster(ctx context.Context, jobID string, cluster model.DatasetCluster) error {
+	dir, err := c.jobDir(jobID)
+	if err != nil {
+		return err
+	}
+	return writeJSONAtomic(filepath.Join(dir, fmt.Sprintf("cluster_%d.json", cluster.Index)), cluster)
+}
+
+// LoadClusters возвращает сохраненные кластеры по индексу. Недочитанные файлы
+// (например, оборванные при сбое) пропускаются, и кластер будет посчитан заново.
+func (c *FileDatasetCheckpoints) LoadClusters(ctx context.Context, jobID string) (map[int]model.DatasetCluster, error) {
+	dir, err := c.jobDir(jobID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w for job %s", model.ErrCheckpointNotFound, jobID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint directory: %w", err)
+	}
+
+	clusters := make(map[int]model.DatasetCluster)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "cluster_") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "cluster_"), ".json"))
+		if err != nil {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+		}
+		var cluster model.DatasetCluster
+		if err := json.Unmarshal(data, &cluster); err != nil || cluster.Index != index {
+			continue
+		}
+		clusters[index] = cluster
+	}
+	return clusters, nil
+}
+
+func (c *FileDatasetCheckpoints) Remove(ctx context.Context, jobID string) error {
+	dir, err := c.jobDir(jobID)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+func (c *FileDatasetCheckpoints) jobDir(jobID string) (string, error) {
+	if !jobIDPattern.MatchString(jobID) {
+		return "", fmt.Errorf("%w: invalid job id %q", model.ErrCheckpointNotFound, jobID)
+	}
+	return filepath.Join(c.dir, jobID), nil
+}
+
+// writeJSONAtomic пишет value во временный файл рядом с path и переименовывает его,
+// так что по пути path всегда лежит либо старая, либо полностью записанная версия
+func writeJSONAtomic(path string, value any) error {
+	return WriteFileAtomic(path, func(file *os.File) error {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	})
+}
+
+// WriteFileAtomic создает файл path через временный файл в том же каталоге:
+// write пишет содержимое, после fsync файл переименовывается в path
+func WriteFileAtomic(path string, write func(file *os.File) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to chmod %s: %w", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to rename %s: %w", path, err)
+	}
+	return nil
+}
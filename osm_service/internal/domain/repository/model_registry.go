@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"osm_service/internal/domain/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// uniqueViolation — код ошибки PostgreSQL при нарушении ограничения UNIQUE
+const uniqueViolation = "23505"
+
+type ModelRegistry interface {
+	RegisterModel(ctx context.Context, m model.RegisteredModel) (model.RegisteredModel, error)
+	ListModels(ctx context.Context, filter model.ModelFilter) ([]model.RegisteredModel, error)
+	GetModel(ctx context.Context, name string) (model.RegisteredModel, error)
+	UpdateStatus(ctx context.Context, name string, status string) (model.RegisteredModel, error)
+	FindBestModel(ctx context.Context, category string, bbox string) (model.RegisteredModel, error)
+}
+
+type PostgresModelRegistry struct {
+	db *sqlx.DB
+}
+
+func NewPostgresModelRegistry(db *sqlx.DB) *PostgresModelRegistry {
+	return &PostgresModelRegistry{db: db}
+}
+
+// registeredModelRow — строка таблицы model_registry с границами покрытия
+type registeredModelRow struct {
+	ID                   int64     `db:"id"`
+	Name                 string    `db:"name"`
+	Category             string    `db:"category"`
+	TrainStart           time.Time `db:"train_start"`
+	TrainEnd             time.Time `db:"train_end"`
+	MinLat               float64   `db:"min_lat"`
+	MinLon               float64   `db:"min_lon"`
+	MaxLat               float64   `db:"max_lat"`
+	MaxLon               float64   `db:"max_lon"`
+	FeatureSchemaVersion string    `db:"feature_schema_version"`
+	SamplingMonths       int       `db:"sampling_months"`
+	Metrics              []byte    `db:"metrics"`
+	Status               string    `db:"status"`
+	CreatedAt            time.Time `db:"created_at"`
+}
+
+const registeredModelColumns = `
+	id, name, category, train_start, train_end,
+	ST_YMin(coverage) AS min_lat, ST_XMin(coverage) AS min_lon,
+	ST_YMax(coverage) AS max_lat, ST_XMax(coverage) AS max_lon,
+	feature_schema_version, sampling_months, metrics, status, created_at`
+
+func (row registeredModelRow) toModel() (model.RegisteredModel, error) {
+	var metrics model.ModelMetrics
+	if err := json.Unmarshal(row.Metrics, &metrics); err != nil {
+		return model.RegisteredModel{}, fmt.Errorf("failed to unmarshal metrics of model %s: %w", row.Name, err)
+	}
+
+	return model.RegisteredModel{
+		ID:                   row.ID,
+		Name:                 row.Name,
+		Category:             row.Category,
+		TrainStart:           row.TrainStart,
+		TrainEnd:             row.TrainEnd,
+		Coverage:             fmt.Sprintf("%f,%f,%f,%f", row.MinLat, row.MinLon, row.MaxLat, row.MaxLon),
+		FeatureSchemaVersion: row.FeatureSchemaVersion,
+		SamplingMonths:       row.SamplingMonths,
+		Metrics:              metrics,
+		Status:               row.Status,
+		CreatedAt:            row.CreatedAt,
+	}, nil
+}
+
+func (r *PostgresModelRegistry) RegisterModel(ctx context.Context, m model.RegisteredModel) (model.RegisteredModel, error) {
+	minLat, minLon, maxLat, maxLon, err := parseBBox(m.Coverage)
+	if err != nil {
+		return model.RegisteredModel{}, fmt.Errorf("invalid coverage: %w", err)
+	}
+
+	metricsJSON, err := json.Marshal(m.Metrics)
+	if err != nil {
+		return model.RegisteredModel{}, fmt.Errorf("failed to marshal metrics: %w", err)
+	}
+
+	query := `
+		INSERT INTO model_registry (
+			name, category, train_start, train_end, coverage,
+			feature_schema_version, sampling_months, metrics, status
+		) VALUES (
+			$1, $2, $3, $4, ST_MakeEnvelope($5, $6, $7, $8, 4326),
+			$9, $10, $11, $12
+		)
+		RETURNING` + registeredModelColumns
+
+	var row registeredModelRow
+	err = r.db.GetContext(ctx, &row, query,
+		m.Name, m.Category, m.TrainStart, m.TrainEnd,
+		minLon, minLat, maxLon, maxLat,
+		m.FeatureSchemaVersion, m.SamplingStep(), metricsJSON, m.Status,
+	)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return model.RegisteredModel{}, fmt.Errorf("%w: %s", model.ErrModelExists, m.Name)
+	}
+	if err != nil {
+		return model.RegisteredModel{}, fmt.Errorf("failed to register model: %w", err)
+	}
+
+	return row.toModel()
+}
+
+func (r *PostgresModelRegistry) ListModels(ctx context.Context, filter model.ModelFilter) ([]model.RegisteredModel, error) {
+	query := `
+		SELECT` + registeredModelColumns + `
+		FROM model_registry
+		WHERE ($1 = '' OR category = $1)
+		AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC`
+
+	var rows []registeredModelRow
+	if err := r.db.SelectContext(ctx, &rows, query, filter.Category, filter.Status); err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+
+	models := make([]model.RegisteredModel, 0, len(rows))
+	for _, row := range rows {
+		m, err := row.toModel()
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, m)
+	}
+	return models, nil
+}
+
+func (r *PostgresModelRegistry) GetModel(ctx context.Context, name string) (model.RegisteredModel, error) {
+	query := `
+		SELECT` + registeredModelColumns + `
+		FROM model_registry
+		WHERE name = $1`
+
+	var row registeredModelRow
+	err := r.db.GetContext(ctx, &row, query, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.RegisteredModel{}, fmt.Errorf("%w: %s", model.ErrModelNotFound, name)
+	}
+	if err != nil {
+		return model.RegisteredModel{}, fmt.Errorf("failed to get model %s: %w", name, err)
+	}
+
+	return row.toModel()
+}
+
+// UpdateStatus меняет статус модели и возвращает обновленную запись
+func (r *PostgresModelRegistry) UpdateStatus(ctx context.Context, name string, status string) (model.RegisteredModel, error) {
+	query := `
+		UPDATE model_registry
+		SET status = $2
+		WHERE name = $1
+		RETURNING` + registeredModelColumns
+
+	var row registeredModelRow
+	err := r.db.GetContext(ctx, &row, query, name, status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.RegisteredModel{}, fmt.Errorf("%w: %s", model.ErrModelNotFound, name)
+	}
+	if err != nil {
+		return model.RegisteredModel{}, fmt.Errorf("failed to update status of model %s: %w", name, err)
+	}
+
+	return row.toModel()
+}
+
+// FindBestModel ищет активную модель категории, покрывающую область. Среди покрывающих
+// выбирается модель с самой маленькой областью (самая локальная), затем с лучшим R².
+// Если покрывающих нет, берется модель с наибольшим пересечением с областью.
+func (r *PostgresModelRegistry) FindBestModel(ctx context.Context, category string, bbox string) (model.RegisteredModel, error) {
+	minLat, minLon, maxLat, maxLon, err := parseBBox(bbox)
+	if err != nil {
+		return model.RegisteredModel{}, fmt.Errorf("invalid bbox format: %w", err)
+	}
+
+	query := `
+		WITH area AS (SELECT ST_MakeEnvelope($2, $3, $4, $5, 4326) AS geom)
+		SELECT` + registeredModelColumns + `
+		FROM model_registry, area
+		WHERE category = $1
+		AND status = '` + model.ModelStatusActive + `'
+		AND ST_Intersects(coverage, area.geom)
+		ORDER BY
+			ST_Covers(coverage, area.geom) DESC,
+			CASE WHEN ST_Covers(coverage, area.geom) THEN ST_Area(coverage) END ASC,
+			ST_Area(ST_Intersection(coverage, area.geom)) DESC,
+			(metrics->>'r2')::float DESC NULLS LAST
+		LIMIT 1`
+
+	var row registeredModelRow
+	err = r.db.GetContext(ctx, &row, query, category, minLon, minLat, maxLon, maxLat)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.RegisteredModel{}, fmt.Errorf("%w for category %s in bbox %s", model.ErrModelNotFound, category, bbox)
+	}
+	if err != nil {
+		return model.RegisteredModel{}, fmt.Errorf("failed to find model: %w", err)
+	}
+
+	return row.toModel()
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,22 +12,40 @@ import (
 	"github.com/serjvanilla/go-overpass"
 )
 
+// DefaultOverpassSlots — число одновременных запросов к Overpass по умолчанию
+const DefaultOverpassSlots = 2
+
+// ErrOverpassUnavailable означает, что запрос к Overpass не выполнен (сеть, таймаут, ошибка сервера)
+var ErrOverpassUnavailable = errors.New("overpass is unavailable")
+
 type OverpassRepository struct {
 	client  *overpass.Client
 	timeout time.Duration
+	slots   int
 }
 
-func NewOverpassRepository(endpoint string, timeout time.Duration) *OverpassRepository {
+// NewOverpassRepository создает клиент Overpass, выполняющий не больше slots запросов одновременно.
+// Публичные серверы Overpass выдают ограниченное число слотов на IP, лишние запросы ждут в клиенте.
+func NewOverpassRepository(endpoint string, timeout time.Duration, slots int) *OverpassRepository {
+	if slots < 1 {
+		slots = DefaultOverpassSlots
+	}
 	httpClient := &http.Client{
 		Timeout: timeout,
 	}
-	client := overpass.NewWithSettings(endpoint, 2, httpClient)
+	client := overpass.NewWithSettings(endpoint, slots, httpClient)
 	return &OverpassRepository{
 		client:  &client,
 		timeout: timeout,
+		slots:   slots,
 	}
 }
 
+// Slots возвращает число одновременных запросов к Overpass
+func (r *OverpassRepository) Slots() int {
+	return r.slots
+}
+
 func (r *OverpassRepository) GetCommercialData(ctx context.Context, bbox string, shopType string) ([]model.OSMElement, error) {
 	query := fmt.Sprintf(`
 		[out:json];
@@ -104,16 +123,35 @@ func (r *OverpassRepository) GetRoadData(ctx context.Context, bbox string, date
 	return elements, nil
 }
 
+// QueryObserver получает длительность и результат каждого запроса к Overpass
+type QueryObserver func(duration time.Duration, err error)
+
+type queryObserverKey struct{}
+
+// WithQueryObserver возвращает контекст, запросы к Overpass с которым сообщаются observer
+func WithQueryObserver(ctx context.Context, observer QueryObserver) context.Context {
+	return context.WithValue(ctx, queryObserverKey{}, observer)
+}
+
 func (r *OverpassRepository) executeQuery(ctx context.Context, query string) (*overpass.Result, error) {
 	startTime := time.Now()
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
+	// Клиент Overpass не принимает контекст, поэтому отмена проверяется перед запросом
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("overpass query cancelled: %w", err)
+	}
+	observer, _ := ctx.Value(queryObserverKey{}).(QueryObserver)
+
 	log.Printf("Starting Overpass API query execution...")
 	result, err := r.client.Query(query)
+	if observer != nil {
+		observer(time.Since(startTime), err)
+	}
 	if err != nil {
 		log.Printf("Overpass API query failed after %v: %v", time.Since(startTime), err)
-		return nil, fmt.Errorf("overpass query failed: %w", err)
+		return nil, fmt.Errorf("overpass query failed: %w: %w", ErrOverpassUnavailable, err)
 	}
 
 	log.Printf("Overpass API query completed successfully in %v", time.Since(startTime))
@@ -146,10 +184,12 @@ func convertToOSMElements(result *overpass.Result) []model.OSMElement {
 	for _, way := range result.Ways {
 		var lat, lon float64
 		count := len(way.Nodes)
+		nodeIDs := make([]int64, 0, count)
 		if count > 0 {
 			for _, node := range way.Nodes {
 				lat += node.Lat
 				lon += node.Lon
+				nodeIDs = append(nodeIDs, node.ID)
 			}
 			lat /= float64(count)
 			lon /= float64(count)
@@ -166,12 +206,13 @@ func convertToOSMElements(result *overpass.Result) []model.OSMElement {
 		}
 
 		elements = append(elements, model.OSMElement{
-			ID:     way.ID,
-			Type:   string(overpass.ElementTypeWay),
-			Lat:    lat,
-			Lon:    lon,
-			Tags:   way.Tags,
-			Bounds: bounds,
+			ID:      way.ID,
+			Type:    string(overpass.ElementTypeWay),
+			Lat:     lat,
+			Lon:     lon,
+			Tags:    way.Tags,
+			Bounds:  bounds,
+			NodeIDs: nodeIDs,
 		})
 	}
 
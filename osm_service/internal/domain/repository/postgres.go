@@ -6,8 +6,6 @@ import (
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"osm_service/internal/domain/model"
-	"strconv"
-	"strings"
 )
 
 type PostGISRepository struct {
@@ -56,39 +54,11 @@ func (r *PostGISRepository) GetByPeriod(
 }
 
 // parseBBox parses a bbox string in format "lat1,lon1,lat2,lon2" into minLat, minLon, maxLat, maxLon.
+// Validation is shared with the rest of the service, see model.ParseBounds.
 func parseBBox(bbox string) (minLat, minLon, maxLat, maxLon float64, err error) {
-	parts := strings.Split(bbox, ",")
-	if len(parts) != 4 {
-		return 0, 0, 0, 0, fmt.Errorf("bbox must have 4 components, got %d", len(parts))
-	}
-
-	minLat, err = strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
-	if err != nil {
-		return 0, 0, 0, 0, fmt.Errorf("invalid minLat: %w", err)
-	}
-	minLon, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	bounds, err := model.ParseBounds(bbox)
 	if err != nil {
-		return 0, 0, 0, 0, fmt.Errorf("invalid minLon: %w", err)
+		return 0, 0, 0, 0, err
 	}
-	maxLat, err = strconv.ParseFloat(strings.TrimSpace(parts[2]), 64)
-	if err != nil {
-		return 0, 0, 0, 0, fmt.Errorf("invalid maxLat: %w", err)
-	}
-	maxLon, err = strconv.ParseFloat(strings.TrimSpace(parts[3]), 64)
-	if err != nil {
-		return 0, 0, 0, 0, fmt.Errorf("invalid maxLon: %w", err)
-	}
-
-	// Validate ranges
-	if minLat < -90 || minLat > 90 || maxLat < -90 || maxLat > 90 {
-		return 0, 0, 0, 0, fmt.Errorf("latitude out of range [-90, 90]")
-	}
-	if minLon < -180 || minLon > 180 || maxLon < -180 || maxLon > 180 {
-		return 0, 0, 0, 0, fmt.Errorf("longitude out of range [-180, 180]")
-	}
-	if minLat > maxLat || minLon > maxLon {
-		return 0, 0, 0, 0, fmt.Errorf("minLat must be <= maxLat and minLon must be <= maxLon")
-	}
-
-	return minLat, minLon, maxLat, maxLon, nil
+	return bounds.MinLat, bounds.MinLon, bounds.MaxLat, bounds.MaxLon, nil
 }
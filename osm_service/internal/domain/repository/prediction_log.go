@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"osm_service/internal/domain/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PredictionLog хранит предсказания моделей и фактические данные для их сравнения
+type PredictionLog interface {
+	SavePrediction(ctx context.Context, record model.PredictionRecord) error
+	SaveGroundTruth(ctx context.Context, truth model.GroundTruth) error
+	ModelAccuracy(ctx context.Context) ([]model.ModelAccuracy, error)
+	ModelDivergence(ctx context.Context) ([]model.ModelDivergence, error)
+}
+
+type PostgresPredictionLog struct {
+	db *sqlx.DB
+}
+
+func NewPostgresPredictionLog(db *sqlx.DB) *PostgresPredictionLog {
+	return &PostgresPredictionLog{db: db}
+}
+
+func (r *PostgresPredictionLog) SavePrediction(ctx context.Context, record model.PredictionRecord) error {
+	const query = `
+		INSERT INTO prediction_log (
+			request_id, role, model_name, bbox, shop_type, prediction_year,
+			activity_level, predicted_new, predicted_closed, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, NOW()
+		)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		record.RequestID, record.Role, record.ModelName,
+		record.BBox, record.ShopType, record.PredictionYear,
+		record.ActivityLevel, record.PredictedNew, record.PredictedClosed,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save prediction: %w", err)
+	}
+	return nil
+}
+
+// SaveGroundTruth сохраняет факты за год; повторная запись для той же области заменяет предыдущую
+func (r *PostgresPredictionLog) SaveGroundTruth(ctx context.Context, truth model.GroundTruth) error {
+	const query = `
+		INSERT INTO prediction_ground_truth (
+			bbox, shop_type, year, actual_new, actual_closed, total_objects, recorded_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, NOW()
+		)
+		ON CONFLICT (bbox, shop_type, year) DO UPDATE SET
+			actual_new = EXCLUDED.actual_new,
+			actual_closed = EXCLUDED.actual_closed,
+			total_objects = EXCLUDED.total_objects,
+			recorded_at = EXCLUDED.recorded_at`
+
+	_, err := r.db.ExecContext(ctx, query,
+		truth.BBox, truth.ShopType, truth.Year,
+		truth.ActualNew, truth.ActualClosed, truth.TotalObjects,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save ground truth: %w", err)
+	}
+	return nil
+}
+
+// ModelAccuracy считает ошибки каждой модели в каждой роли по предсказаниям, для которых известны факты
+func (r *PostgresPredictionLog) ModelAccuracy(ctx context.Context) ([]model.ModelAccuracy, error) {
+	const query = `
+		SELECT
+			p.model_name,
+			p.role,
+			COUNT(*) AS predictions,
+			COUNT(g.year) AS evaluated,
+			AVG(ABS(p.predicted_new - g.actual_new)) AS mae_new,
+			AVG(ABS(p.predicted_closed - g.actual_closed)) AS mae_closed,
+			SQRT(AVG(POWER(p.predicted_new - g.actual_new, 2))) AS rmse_new
+		FROM prediction_log p
+		LEFT JOIN prediction_ground_truth g
+			ON g.bbox = p.bbox
+			AND g.shop_type = p.shop_type
+			AND g.year = p.prediction_year
+		GROUP BY p.model_name, p.role
+		ORDER BY p.model_name, p.role`
+
+	var rows []struct {
+		ModelName   string   `db:"model_name"`
+		Role        string   `db:"role"`
+		Predictions int      `db:"predictions"`
+		Evaluated   int      `db:"evaluated"`
+		MAENew      *float64 `db:"mae_new"`
+		MAEClosed   *float64 `db:"mae_closed"`
+		RMSENew     *float64 `db:"rmse_new"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("failed to calculate model accuracy: %w", err)
+	}
+
+	result := make([]model.ModelAccuracy, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, model.ModelAccuracy{
+			ModelName:   row.ModelName,
+			Role:        row.Role,
+			Predictions: row.Predictions,
+			Evaluated:   row.Evaluated,
+			MAENew:      row.MAENew,
+			MAEClosed:   row.MAEClosed,
+			RMSENew:     row.RMSENew,
+		})
+	}
+	return result, nil
+}
+
+// ModelDivergence сравнивает теневые предсказания с основными из того же запроса.
+// Пакетный запрос записывает предсказания всех областей под одним идентификатором,
+// поэтому пары сопоставляются и по области.
+func (r *PostgresPredictionLog) ModelDivergence(ctx context.Context) ([]model.ModelDivergence, error) {
+	query := `
+		SELECT
+			p.model_name AS primary_model,
+			s.model_name AS shadow_model,
+			COUNT(*) AS pairs,
+			AVG(ABS(p.activity_level - s.activity_level)) AS mean_activity_diff,
+			MAX(ABS(p.activity_level - s.activity_level)) AS max_activity_diff,
+			AVG(ABS(p.predicted_new - s.predicted_new)) AS mean_predicted_new_diff
+		FROM prediction_log p
+		JOIN prediction_log s
+			ON s.request_id = p.request_id
+			AND s.bbox = p.bbox
+			AND s.shop_type = p.shop_type
+			AND s.prediction_year = p.prediction_year
+			AND s.role = '` + model.RoleShadow + `'
+		WHERE p.role = '` + model.RolePrimary + `'
+		GROUP BY p.model_name, s.model_name
+		ORDER BY p.model_name, s.model_name`
+
+	var rows []struct {
+		PrimaryModel         string  `db:"primary_model"`
+		ShadowModel          string  `db:"shadow_model"`
+		Pairs                int     `db:"pairs"`
+		MeanActivityDiff     float64 `db:"mean_activity_diff"`
+		MaxActivityDiff      float64 `db:"max_activity_diff"`
+		MeanPredictedNewDiff float64 `db:"mean_predicted_new_diff"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("failed to calculate model divergence: %w", err)
+	}
+
+	result := make([]model.ModelDivergence, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, model.ModelDivergence(row))
+	}
+	return result, nil
+}
@@ -0,0 +1,242 @@
+package forecast
+
+import (
+	"errors"
+	"math"
+)
+
+// maxAROrder ограничивает порядок авторегрессии: ряды по годам короткие
+const maxAROrder = 2
+
+// ARIMA — модель ARIMA(p,d,0): авторегрессия порядка p на d-кратно
+// продифференцированном ряде, коэффициенты оцениваются МНК
+type ARIMA struct {
+	P         int
+	D         int
+	Intercept float64
+	Coef      []float64
+
+	history []float64 // последние значения исходного ряда для интегрирования
+	diffs   []float64 // продифференцированный ряд
+	sigma   float64
+	sse     float64
+	n       int
+}
+
+// FitARIMA подбирает порядок p ∈ [0, 2] по AIC для ряда, продифференцированного d раз
+func FitARIMA(series []float64, d int) (*ARIMA, error) {
+	if d < 0 {
+		return nil, errors.New("differencing order must be non-negative")
+	}
+
+	diffs := append([]float64(nil), series...)
+	for i := 0; i < d; i++ {
+		diffs = difference(diffs)
+	}
+
+	// Наибольший порядок, для которого наблюдений хотя бы на два больше, чем параметров
+	maxP := -1
+	for p := 0; p <= maxAROrder && len(diffs)-p >= p+3; p++ {
+		maxP = p
+	}
+
+	// Все порядки оцениваются по одним и тем же наблюдениям начиная с maxP,
+	// иначе AIC моделей считался бы по разному числу ошибок
+	var best *ARIMA
+	for p := 0; p <= maxP; p++ {
+		candidate, err := fitAR(diffs, p, maxP)
+		if err != nil {
+			continue
+		}
+		candidate.D = d
+		candidate.history = append([]float64(nil), series...)
+		if best == nil || candidate.AIC() < best.AIC() {
+			best = candidate
+		}
+	}
+
+	if best == nil {
+		return nil, ErrShortSeries
+	}
+	return best, nil
+}
+
+// fitAR оценивает AR(p) по наблюдениям diffs[start:], start >= p
+func fitAR(diffs []float64, p int, start int) (*ARIMA, error) {
+	rows := len(diffs) - start
+	cols := p + 1
+
+	// Нормальные уравнения XᵀX β = Xᵀy, где X = [1, y_{t-1}, ..., y_{t-p}]
+	xtx := make([][]float64, cols)
+	for i := range xtx {
+		xtx[i] = make([]float64, cols)
+	}
+	xty := make([]float64, cols)
+
+	row := make([]float64, cols)
+	for t := start; t < len(diffs); t++ {
+		row[0] = 1
+		for k := 1; k <= p; k++ {
+			row[k] = diffs[t-k]
+		}
+		for i := 0; i < cols; i++ {
+			xty[i] += row[i] * diffs[t]
+			for j := 0; j < cols; j++ {
+				xtx[i][j] += row[i] * row[j]
+			}
+		}
+	}
+
+	beta, err := solve(xtx, xty)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ARIMA{
+		P:         p,
+		Intercept: beta[0],
+		Coef:      beta[1:],
+		diffs:     diffs,
+		n:         rows,
+	}
+
+	for t := start; t < len(diffs); t++ {
+		e := diffs[t] - m.predictDiff(diffs[:t])
+		m.sse += e * e
+	}
+	dof := rows - cols
+	if dof < 1 {
+		dof = 1
+	}
+	m.sigma = math.Sqrt(m.sse / float64(dof))
+
+	return m, nil
+}
+
+// predictDiff предсказывает следующее значение продифференцированного ряда
+func (m *ARIMA) predictDiff(past []float64) float64 {
+	value := m.Intercept
+	for k, c := range m.Coef {
+		value += c * past[len(past)-1-k]
+	}
+	return value
+}
+
+func (m *ARIMA) Method() string {
+	return MethodARIMA
+}
+
+// Predict рекурсивно прогнозирует разности и интегрирует их обратно.
+// Стандартная ошибка считается через ψ-веса интегрированного процесса.
+func (m *ARIMA) Predict(h int) (float64, float64) {
+	extended := append([]float64(nil), m.diffs...)
+	for i := 0; i < h; i++ {
+		extended = append(extended, m.predictDiff(extended))
+	}
+	forecastDiffs := extended[len(m.diffs):]
+
+	value := integrate(m.history, m.D, forecastDiffs)
+
+	// ψ-веса AR части
+	psi := make([]float64, h)
+	psi[0] = 1
+	for j := 1; j < h; j++ {
+		for k, c := range m.Coef {
+			if j-1-k >= 0 {
+				psi[j] += c * psi[j-1-k]
+			}
+		}
+	}
+	// Каждое дифференцирование превращает веса в накопленные суммы
+	for i := 0; i < m.D; i++ {
+		for j := 1; j < h; j++ {
+			psi[j] += psi[j-1]
+		}
+	}
+
+	var variance float64
+	for _, w := range psi {
+		variance += w * w
+	}
+
+	return value, m.sigma * math.Sqrt(variance)
+}
+
+func (m *ARIMA) AIC() float64 {
+	return aic(m.sse, m.n, m.P+1)
+}
+
+// integrate восстанавливает последнее значение исходного ряда по прогнозу d-х разностей
+func integrate(history []float64, d int, forecastDiffs []float64) float64 {
+	if d == 0 {
+		return forecastDiffs[len(forecastDiffs)-1]
+	}
+
+	// Последние значения рядов разностей порядков 0..d-1
+	levels := make([]float64, d)
+	current := append([]float64(nil), history...)
+	for i := 0; i < d; i++ {
+		levels[i] = current[len(current)-1]
+		current = difference(current)
+	}
+
+	var value float64
+	for _, diff := range forecastDiffs {
+		value = diff
+		for i := d - 1; i >= 0; i-- {
+			levels[i] += value
+			value = levels[i]
+		}
+	}
+	return value
+}
+
+func difference(series []float64) []float64 {
+	if len(series) < 2 {
+		return nil
+	}
+	result := make([]float64, len(series)-1)
+	for i := 1; i < len(series); i++ {
+		result[i-1] = series[i] - series[i-1]
+	}
+	return result
+}
+
+// solve решает систему линейных уравнений методом Гаусса с выбором главного элемента
+func solve(a [][]float64, b []float64) ([]float64, error) {
+	n := len(b)
+	m := make([][]float64, n)
+	for i := range a {
+		m[i] = append(append([]float64(nil), a[i]...), b[i])
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-12 {
+			return nil, errors.New("singular matrix")
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+
+		for row := col + 1; row < n; row++ {
+			factor := m[row][col] / m[col][col]
+			for k := col; k <= n; k++ {
+				m[row][k] -= factor * m[col][k]
+			}
+		}
+	}
+
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		x[i] = m[i][n]
+		for k := i + 1; k < n; k++ {
+			x[i] -= m[i][k] * x[k]
+		}
+		x[i] /= m[i][i]
+	}
+	return x, nil
+}
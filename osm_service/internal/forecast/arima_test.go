@@ -0,0 +1,42 @@
+package forecast
+
+import (
+	"math"
+	"testing"
+)
+
+func TestARIMALinearSeries(t *testing.T) {
+	series := []float64{10, 12, 14, 16, 18, 20}
+
+	m, err := FitARIMA(series, 1)
+	if err != nil {
+		t.Fatalf("FitARIMA: %v", err)
+	}
+	// Разности постоянны: модель сводится к случайному блужданию со сносом 2
+	if m.P != 0 || math.Abs(m.Intercept-2) > 1e-9 {
+		t.Errorf("got ARIMA(%d,1,0) with intercept %v, want ARIMA(0,1,0) with drift 2", m.P, m.Intercept)
+	}
+	for h := 1; h <= 5; h++ {
+		value, stdErr := m.Predict(h)
+		if want := 20 + 2*float64(h); math.Abs(value-want) > 1e-9 {
+			t.Errorf("Predict(%d) = %v, want %v", h, value, want)
+		}
+		if stdErr > 1e-9 {
+			t.Errorf("Predict(%d) stdErr = %v, want 0 on an exact trend", h, stdErr)
+		}
+	}
+}
+
+func TestARIMAIntervalsWiden(t *testing.T) {
+	m, err := FitARIMA(noisySeries, 1)
+	if err != nil {
+		t.Fatalf("FitARIMA: %v", err)
+	}
+	checkWidening(t, m)
+}
+
+func TestFitARIMAShortSeries(t *testing.T) {
+	if _, err := FitARIMA([]float64{1, 2, 3}, 1); err != ErrShortSeries {
+		t.Errorf("err = %v, want ErrShortSeries", err)
+	}
+}
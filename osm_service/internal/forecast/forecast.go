@@ -0,0 +1,183 @@
+// Package forecast содержит простые модели временных рядов (Holt, damped trend,
+// ARIMA) для прогноза количества объектов без обращения к ML сервису.
+package forecast
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"osm_service/internal/domain/model"
+	"sort"
+)
+
+const (
+	MethodHolt   = "holt"
+	MethodDamped = "holt_damped"
+	MethodARIMA  = "arima"
+	MethodAuto   = "auto"
+)
+
+// ErrShortSeries возвращается, если ряд слишком короткий для выбранного метода
+var ErrShortSeries = errors.New("series is too short")
+
+// Model — обученная модель ряда
+type Model interface {
+	// Method возвращает название метода
+	Method() string
+	// Predict возвращает прогноз и стандартное отклонение ошибки на h шагов вперед
+	Predict(h int) (value float64, stdErr float64)
+	// AIC возвращает информационный критерий Акаике для выбора порядка внутри метода
+	AIC() float64
+}
+
+// Series извлекает ряд количества объектов, упорядоченный по периодам
+func Series(data []model.HistoricalData) []float64 {
+	sorted := append([]model.HistoricalData(nil), data...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Period < sorted[j].Period
+	})
+
+	series := make([]float64, len(sorted))
+	for i, item := range sorted {
+		series[i] = float64(item.TotalObjects)
+	}
+	return series
+}
+
+// maxHoldout — число последних точек ряда, на которых MethodAuto сравнивает методы
+const maxHoldout = 3
+
+// autoMethods — методы, из которых выбирает MethodAuto, от простого к сложному:
+// при равной ошибке выбирается более простой
+var autoMethods = []string{MethodHolt, MethodDamped, MethodARIMA}
+
+// Fit обучает модель выбранного метода. MethodAuto выбирает метод с наименьшей
+// ошибкой прогноза на один шаг на последних точках ряда (см. fitAuto).
+func Fit(series []float64, method string) (Model, error) {
+	switch method {
+	case MethodHolt:
+		return FitHolt(series, false)
+	case MethodDamped:
+		return FitHolt(series, true)
+	case MethodARIMA:
+		return FitARIMA(series, 1)
+	case MethodAuto, "":
+		return fitAuto(series)
+	default:
+		return nil, fmt.Errorf("unknown forecast method: %s", method)
+	}
+}
+
+// fitAuto сравнивает методы на общей отложенной выборке: для каждой из последних
+// maxHoldout точек модель обучается на предшествующей части ряда и прогнозирует
+// точку на шаг вперед. AIC методов сравнивать нельзя: Холт и ARIMA считают ошибки
+// по разному числу наблюдений. Если ряд слишком короткий для проверки, выбирается
+// самый простой из обученных методов.
+func fitAuto(series []float64) (Model, error) {
+	fitted := make(map[string]Model)
+	minTrain := 0
+	var lastErr error
+	for _, method := range autoMethods {
+		m, err := Fit(series, method)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		fitted[method] = m
+		minTrain = max(minTrain, minLength(method))
+	}
+	if len(fitted) == 0 {
+		return nil, lastErr
+	}
+
+	var best Model
+	bestSSE := math.Inf(1)
+	if holdout := min(maxHoldout, len(series)-minTrain); holdout > 0 {
+		for _, method := range autoMethods {
+			m, ok := fitted[method]
+			if !ok {
+				continue
+			}
+			if sse, err := holdoutSSE(series, method, holdout); err == nil && sse < bestSSE {
+				best, bestSSE = m, sse
+			}
+		}
+	}
+	if best == nil {
+		for _, method := range autoMethods {
+			if m, ok := fitted[method]; ok {
+				return m, nil
+			}
+		}
+	}
+	return best, nil
+}
+
+// holdoutSSE возвращает сумму квадратов ошибок прогноза на один шаг для последних
+// holdout точек ряда, обучая модель только на точках до прогнозируемой
+func holdoutSSE(series []float64, method string, holdout int) (float64, error) {
+	var sse float64
+	for t := len(series) - holdout; t < len(series); t++ {
+		m, err := Fit(series[:t], method)
+		if err != nil {
+			return 0, err
+		}
+		predicted, _ := m.Predict(1)
+		e := series[t] - predicted
+		sse += e * e
+	}
+	return sse, nil
+}
+
+// minLength возвращает минимальную длину ряда, на которой обучается метод
+func minLength(method string) int {
+	if method == MethodARIMA {
+		// ARIMA(0,1,0) с константой: три разности
+		return 4
+	}
+	return 3
+}
+
+// Forecast строит прогноз на horizon периодов вперед с интервалами уровня level
+func Forecast(data []model.HistoricalData, method string, horizon int, level float64) (model.Forecast, error) {
+	if horizon < 1 {
+		return model.Forecast{}, fmt.Errorf("horizon must be positive, got %d", horizon)
+	}
+	if level <= 0 || level >= 1 {
+		return model.Forecast{}, fmt.Errorf("level must be in (0, 1), got %f", level)
+	}
+
+	fitted, err := Fit(Series(data), method)
+	if err != nil {
+		return model.Forecast{}, err
+	}
+
+	z := math.Sqrt2 * math.Erfinv(level)
+	result := model.Forecast{
+		Method: fitted.Method(),
+		Level:  level,
+		Points: make([]model.ForecastPoint, horizon),
+	}
+	for h := 1; h <= horizon; h++ {
+		value, stdErr := fitted.Predict(h)
+		result.Points[h-1] = model.ForecastPoint{
+			Step:  h,
+			Value: value,
+			// Количество объектов не может быть отрицательным
+			Lower: math.Max(0, value-z*stdErr),
+			Upper: value + z*stdErr,
+		}
+	}
+
+	return result, nil
+}
+
+// aic рассчитывает критерий Акаике по сумме квадратов ошибок
+func aic(sse float64, n, params int) float64 {
+	if n == 0 {
+		return math.Inf(1)
+	}
+	// Защита от логарифма нуля на идеально описываемых рядах
+	sse = math.Max(sse, 1e-9)
+	return float64(n)*math.Log(sse/float64(n)) + 2*float64(params)
+}
@@ -0,0 +1,101 @@
+package forecast
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"osm_service/internal/domain/model"
+)
+
+func TestForecastIntervals(t *testing.T) {
+	// Периоды перемешаны: Forecast сам упорядочивает ряд
+	var data []model.HistoricalData
+	for i, value := range noisySeries {
+		data = append(data, model.HistoricalData{Period: fmt.Sprintf("%d-01-01", 2015+i), TotalObjects: int(value)})
+	}
+	data[0], data[len(data)-1] = data[len(data)-1], data[0]
+
+	for _, method := range []string{MethodHolt, MethodDamped, MethodARIMA} {
+		t.Run(method, func(t *testing.T) {
+			result, err := Forecast(data, method, 4, 0.95)
+			if err != nil {
+				t.Fatalf("Forecast: %v", err)
+			}
+			if result.Method != method || len(result.Points) != 4 {
+				t.Fatalf("got %s with %d points, want %s with 4", result.Method, len(result.Points), method)
+			}
+			prevWidth := 0.0
+			for i, point := range result.Points {
+				if point.Step != i+1 {
+					t.Errorf("point %d has step %d", i, point.Step)
+				}
+				if point.Lower > point.Value || point.Value > point.Upper {
+					t.Errorf("step %d: value %v outside [%v, %v]", point.Step, point.Value, point.Lower, point.Upper)
+				}
+				width := point.Upper - point.Lower
+				if width <= prevWidth {
+					t.Errorf("step %d: interval width %v, not wider than %v", point.Step, width, prevWidth)
+				}
+				prevWidth = width
+			}
+		})
+	}
+}
+
+func TestFitAutoLinearSeries(t *testing.T) {
+	series := []float64{10, 12, 14, 16, 18, 20, 22, 24}
+
+	fitted, err := Fit(series, MethodAuto)
+	if err != nil {
+		t.Fatalf("Fit: %v", err)
+	}
+	// Все методы описывают линейный ряд без ошибок, при равенстве выбирается самый простой
+	if fitted.Method() != MethodHolt {
+		t.Errorf("Method = %s, want %s", fitted.Method(), MethodHolt)
+	}
+	if value, _ := fitted.Predict(1); math.Abs(value-26) > 1e-6 {
+		t.Errorf("Predict(1) = %v, want 26", value)
+	}
+}
+
+func TestFitAutoPrefersLowerHoldoutError(t *testing.T) {
+	// Прирост каждый год уменьшается в 0.8 раза — это AR(1) на разностях,
+	// и ARIMA прогнозирует последние точки точнее линейного тренда Холта
+	series := []float64{100, 140, 172, 197.6, 218.08, 234.46, 247.57, 258.06, 266.45}
+
+	fitted, err := Fit(series, MethodAuto)
+	if err != nil {
+		t.Fatalf("Fit: %v", err)
+	}
+	if fitted.Method() != MethodARIMA {
+		t.Errorf("Method = %s, want %s", fitted.Method(), MethodARIMA)
+	}
+
+	holtSSE, err := holdoutSSE(series, MethodHolt, maxHoldout)
+	if err != nil {
+		t.Fatalf("holdoutSSE(holt): %v", err)
+	}
+	arimaSSE, err := holdoutSSE(series, MethodARIMA, maxHoldout)
+	if err != nil {
+		t.Fatalf("holdoutSSE(arima): %v", err)
+	}
+	if arimaSSE >= holtSSE {
+		t.Errorf("holdout SSE: arima %v, holt %v; want arima lower", arimaSSE, holtSSE)
+	}
+}
+
+func TestFitAutoShortSeries(t *testing.T) {
+	// На четырех точках проверять методы не на чем: берется самый простой
+	fitted, err := Fit([]float64{5, 7, 8, 11}, MethodAuto)
+	if err != nil {
+		t.Fatalf("Fit: %v", err)
+	}
+	if fitted.Method() != MethodHolt {
+		t.Errorf("Method = %s, want %s", fitted.Method(), MethodHolt)
+	}
+
+	if _, err := Fit([]float64{5, 7}, MethodAuto); err != ErrShortSeries {
+		t.Errorf("err = %v, want ErrShortSeries", err)
+	}
+}
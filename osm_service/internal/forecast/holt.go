@@ -0,0 +1,117 @@
+package forecast
+
+import (
+	"math"
+)
+
+// Holt — модель линейного тренда Холта, в том числе с затуханием тренда
+type Holt struct {
+	Alpha  float64 // сглаживание уровня
+	Beta   float64 // сглаживание тренда
+	Phi    float64 // коэффициент затухания тренда (1 для обычной модели Холта)
+	Damped bool
+
+	level float64
+	trend float64
+	sigma float64
+	sse   float64
+	n     int
+}
+
+// FitHolt подбирает параметры сглаживания перебором по сетке, минимизируя
+// сумму квадратов ошибок прогноза на один шаг
+func FitHolt(series []float64, damped bool) (*Holt, error) {
+	if len(series) < 3 {
+		return nil, ErrShortSeries
+	}
+
+	phis := []float64{1}
+	if damped {
+		phis = []float64{0.8, 0.85, 0.9, 0.95, 0.98}
+	}
+
+	var best *Holt
+	for _, phi := range phis {
+		for alpha := 0.05; alpha < 1; alpha += 0.05 {
+			for beta := 0.05; beta < 1; beta += 0.05 {
+				candidate := &Holt{Alpha: alpha, Beta: beta, Phi: phi, Damped: damped}
+				candidate.fit(series)
+				if best == nil || candidate.sse < best.sse {
+					best = candidate
+				}
+			}
+		}
+	}
+
+	return best, nil
+}
+
+func (m *Holt) fit(series []float64) {
+	level := series[0]
+	trend := series[1] - series[0]
+
+	var sse float64
+	for _, y := range series[1:] {
+		predicted := level + m.Phi*trend
+		e := y - predicted
+		sse += e * e
+
+		newLevel := predicted + m.Alpha*e
+		trend = m.Beta*(newLevel-level) + (1-m.Beta)*m.Phi*trend
+		level = newLevel
+	}
+
+	m.level = level
+	m.trend = trend
+	m.sse = sse
+	m.n = len(series) - 1
+
+	params := 4 // alpha, beta, начальные уровень и тренд
+	if m.Damped {
+		params++
+	}
+	dof := m.n - params
+	if dof < 1 {
+		dof = 1
+	}
+	m.sigma = math.Sqrt(sse / float64(dof))
+}
+
+func (m *Holt) Method() string {
+	if m.Damped {
+		return MethodDamped
+	}
+	return MethodHolt
+}
+
+// Predict использует дисперсию ошибки модели ETS(A,Ad,N):
+// Var(h) = σ²(1 + Σ_{j=1}^{h-1} c_j²), c_j = α(1 + β(φ + ... + φ^j))
+func (m *Holt) Predict(h int) (float64, float64) {
+	var dampSum, phiPow float64
+	phiPow = 1
+	for i := 1; i <= h; i++ {
+		phiPow *= m.Phi
+		dampSum += phiPow
+	}
+	value := m.level + dampSum*m.trend
+
+	variance := 1.0
+	var cumPhi float64
+	phiPow = 1
+	for j := 1; j < h; j++ {
+		phiPow *= m.Phi
+		cumPhi += phiPow
+		c := m.Alpha * (1 + m.Beta*cumPhi)
+		variance += c * c
+	}
+
+	return value, m.sigma * math.Sqrt(variance)
+}
+
+func (m *Holt) AIC() float64 {
+	params := 4
+	if m.Damped {
+		params++
+	}
+	return aic(m.sse, m.n, params)
+}
@@ -0,0 +1,89 @@
+package forecast
+
+import (
+	"math"
+	"testing"
+)
+
+// noisySeries — ряд с трендом и шумом, на котором у моделей ненулевая ошибка
+var noisySeries = []float64{50, 54, 53, 58, 61, 60, 66, 68, 67, 72}
+
+func TestHoltLinearSeries(t *testing.T) {
+	series := []float64{10, 12, 14, 16, 18, 20}
+
+	m, err := FitHolt(series, false)
+	if err != nil {
+		t.Fatalf("FitHolt: %v", err)
+	}
+	for h := 1; h <= 5; h++ {
+		value, stdErr := m.Predict(h)
+		if want := 20 + 2*float64(h); math.Abs(value-want) > 1e-9 {
+			t.Errorf("Predict(%d) = %v, want %v", h, value, want)
+		}
+		if stdErr > 1e-9 {
+			t.Errorf("Predict(%d) stdErr = %v, want 0 on an exact trend", h, stdErr)
+		}
+	}
+}
+
+func TestDampedHoltLinearSeries(t *testing.T) {
+	series := []float64{10, 12, 14, 16, 18, 20}
+
+	m, err := FitHolt(series, true)
+	if err != nil {
+		t.Fatalf("FitHolt: %v", err)
+	}
+	if m.Phi >= 1 {
+		t.Fatalf("Phi = %v, want damping below 1", m.Phi)
+	}
+
+	// Прогноз растет, но приращения затухают и остаются ниже линейного тренда
+	prev, _ := m.Predict(1)
+	prevStep := prev - 20
+	if prevStep <= 0 || prevStep > 2+1e-9 {
+		t.Fatalf("first step = %v, want in (0, 2]", prevStep)
+	}
+	for h := 2; h <= 10; h++ {
+		value, _ := m.Predict(h)
+		step := value - prev
+		if step <= 0 || step >= prevStep {
+			t.Errorf("step %d = %v after %v, want positive and damped", h, step, prevStep)
+		}
+		if value > 20+2*float64(h) {
+			t.Errorf("Predict(%d) = %v above the linear trend", h, value)
+		}
+		prev, prevStep = value, step
+	}
+}
+
+func TestHoltIntervalsWiden(t *testing.T) {
+	for _, damped := range []bool{false, true} {
+		m, err := FitHolt(noisySeries, damped)
+		if err != nil {
+			t.Fatalf("FitHolt(damped=%v): %v", damped, err)
+		}
+		checkWidening(t, m)
+	}
+}
+
+func TestFitHoltShortSeries(t *testing.T) {
+	if _, err := FitHolt([]float64{1, 2}, false); err != ErrShortSeries {
+		t.Errorf("err = %v, want ErrShortSeries", err)
+	}
+}
+
+// checkWidening проверяет, что ошибка прогноза положительна и растет с горизонтом
+func checkWidening(t *testing.T, m Model) {
+	t.Helper()
+	_, prev := m.Predict(1)
+	if prev <= 0 {
+		t.Fatalf("%s: stdErr(1) = %v, want positive on a noisy series", m.Method(), prev)
+	}
+	for h := 2; h <= 6; h++ {
+		_, stdErr := m.Predict(h)
+		if stdErr <= prev {
+			t.Errorf("%s: stdErr(%d) = %v, not wider than stdErr(%d) = %v", m.Method(), h, stdErr, h-1, prev)
+		}
+		prev = stdErr
+	}
+}
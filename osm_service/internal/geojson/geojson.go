@@ -0,0 +1,61 @@
+// Package geojson описывает объекты GeoJSON (RFC 7946) для выгрузки в ГИС
+package geojson
+
+import "osm_service/internal/domain/model"
+
+// MediaType — тип содержимого GeoJSON
+const MediaType = "application/geo+json"
+
+// FeatureCollection — набор объектов GeoJSON
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature — объект GeoJSON с геометрией и атрибутами
+type Feature struct {
+	Type       string         `json:"type"`
+	Geometry   Geometry       `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+// Geometry — геометрия GeoJSON. Координаты задаются в порядке [долгота, широта].
+type Geometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+// NewFeatureCollection возвращает набор из features
+func NewFeatureCollection(features ...Feature) FeatureCollection {
+	if features == nil {
+		features = []Feature{}
+	}
+	return FeatureCollection{Type: "FeatureCollection", Features: features}
+}
+
+// NewFeature возвращает объект с геометрией и атрибутами
+func NewFeature(geometry Geometry, properties map[string]any) Feature {
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	return Feature{Type: "Feature", Geometry: geometry, Properties: properties}
+}
+
+// Point возвращает точку с координатами lat, lon
+func Point(lat, lon float64) Geometry {
+	return Geometry{Type: "Point", Coordinates: []float64{lon, lat}}
+}
+
+// BoundsPolygon возвращает прямоугольник bounds; внешнее кольцо обходится против часовой стрелки
+func BoundsPolygon(bounds model.Bounds) Geometry {
+	return Geometry{
+		Type: "Polygon",
+		Coordinates: [][][]float64{{
+			{bounds.MinLon, bounds.MinLat},
+			{bounds.MaxLon, bounds.MinLat},
+			{bounds.MaxLon, bounds.MaxLat},
+			{bounds.MinLon, bounds.MaxLat},
+			{bounds.MinLon, bounds.MinLat},
+		}},
+	}
+}
@@ -0,0 +1,72 @@
+package inference
+
+import (
+	"math"
+	"osm_service/internal/domain/model"
+	"sort"
+)
+
+// Explain раскладывает activity_level модели на базовое значение и вклады признаков.
+// Для линейной модели вклад — коэффициент на нормализованный признак, для ансамблей
+// деревьев — сумма изменений значения узлов на пути, где ветвление шло по признаку.
+// Сумма вкладов и базового значения равна результату Evaluate.
+func (m *Model) Explain(features model.FeatureVector) (*model.Explanation, error) {
+	x, err := m.vector(features)
+	if err != nil {
+		return nil, err
+	}
+
+	contributions := make([]float64, len(x))
+	explanation := &model.Explanation{Model: m.Name}
+
+	switch m.Estimator.Type {
+	case TypeLinear:
+		explanation.Method = model.ExplanationLinear
+		explanation.BaseValue = m.Estimator.Intercept
+		for i, c := range m.Estimator.Coef {
+			contributions[i] = c * x[i]
+		}
+	case TypeRandomForest:
+		explanation.Method = model.ExplanationTreePath
+		weight := 1 / float64(len(m.Estimator.Trees))
+		for _, tree := range m.Estimator.Trees {
+			explanation.BaseValue += weight * tree.pathContributions(x, contributions, weight)
+		}
+	case TypeGradientBoosting:
+		explanation.Method = model.ExplanationTreePath
+		explanation.BaseValue = m.Estimator.InitValue
+		for _, tree := range m.Estimator.Trees {
+			explanation.BaseValue += m.Estimator.LearningRate * tree.pathContributions(x, contributions, m.Estimator.LearningRate)
+		}
+	}
+
+	for i, name := range m.FeatureNames {
+		value, _ := featureValue(features, name)
+		explanation.Contributions = append(explanation.Contributions, model.FeatureContribution{
+			Feature:      name,
+			Value:        value,
+			Contribution: contributions[i],
+		})
+	}
+	sort.SliceStable(explanation.Contributions, func(i, j int) bool {
+		return math.Abs(explanation.Contributions[i].Contribution) > math.Abs(explanation.Contributions[j].Contribution)
+	})
+
+	return explanation, nil
+}
+
+// pathContributions проходит дерево для x, добавляет weight × изменение значения узла
+// к вкладу признака, по которому шло ветвление, и возвращает значение корня
+func (t Tree) pathContributions(x []float64, contributions []float64, weight float64) float64 {
+	node := 0
+	for t.ChildrenLeft[node] != -1 {
+		feature := t.Feature[node]
+		next := t.ChildrenRight[node]
+		if x[feature] <= t.Threshold[node] {
+			next = t.ChildrenLeft[node]
+		}
+		contributions[feature] += weight * (t.Value[next] - t.Value[node])
+		node = next
+	}
+	return t.Value[0]
+}
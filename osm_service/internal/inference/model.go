@@ -0,0 +1,304 @@
+// Package inference загружает модели, экспортированные train_models.py в JSON,
+// и считает предсказания без обращения к ML сервису.
+package inference
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"osm_service/internal/domain/model"
+	"sort"
+)
+
+// FormatVersion — версия формата экспорта моделей, которую понимает пакет
+const FormatVersion = "1"
+
+const (
+	TypeLinear           = "linear"
+	TypeRandomForest     = "random_forest"
+	TypeGradientBoosting = "gradient_boosting"
+)
+
+// ErrModelNotFound возвращается, если для запроса нет подходящей модели
+var ErrModelNotFound = model.ErrModelNotFound
+
+// Model — экспортированная модель вместе со скейлером и метаданными
+type Model struct {
+	FormatVersion string    `json:"format_version"`
+	Name          string    `json:"name"`
+	ShopType      string    `json:"shop_type"`
+	TrainPeriod   string    `json:"train_period"` // например "2019-2023"
+	BBox          string    `json:"bbox"`         // область обучения "minLat,minLon,maxLat,maxLon"
+	FeatureNames  []string  `json:"feature_names"`
+	Scaler        *Scaler   `json:"scaler"`
+	Estimator     Estimator `json:"model"`
+}
+
+// Scaler повторяет sklearn StandardScaler: (x - mean) / scale
+type Scaler struct {
+	Mean  []float64 `json:"mean"`
+	Scale []float64 `json:"scale"`
+}
+
+// Estimator описывает линейную модель или ансамбль деревьев
+type Estimator struct {
+	Type string `json:"type"`
+
+	// Линейная модель
+	Coef      []float64 `json:"coef,omitempty"`
+	Intercept float64   `json:"intercept,omitempty"`
+
+	// Ансамбли деревьев
+	Trees        []Tree  `json:"trees,omitempty"`
+	LearningRate float64 `json:"learning_rate,omitempty"` // только для градиентного бустинга
+	InitValue    float64 `json:"init_value,omitempty"`    // только для градиентного бустинга
+}
+
+// Tree — дерево решений в представлении sklearn tree_: узел i уходит влево,
+// если x[Feature[i]] <= Threshold[i]; у листьев ChildrenLeft[i] == -1
+type Tree struct {
+	ChildrenLeft  []int     `json:"children_left"`
+	ChildrenRight []int     `json:"children_right"`
+	Feature       []int     `json:"feature"`
+	Threshold     []float64 `json:"threshold"`
+	Value         []float64 `json:"value"`
+}
+
+// Load читает модель из JSON файла и проверяет ее структуру
+func Load(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model: %w", err)
+	}
+
+	var m Model
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode model %s: %w", path, err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("invalid model %s: %w", path, err)
+	}
+
+	return &m, nil
+}
+
+func (m *Model) validate() error {
+	if m.FormatVersion != FormatVersion {
+		return fmt.Errorf("unsupported format version %q", m.FormatVersion)
+	}
+
+	n := len(m.FeatureNames)
+	if n == 0 {
+		return errors.New("feature_names is empty")
+	}
+	for _, name := range m.FeatureNames {
+		if _, err := featureValue(model.FeatureVector{}, name); err != nil {
+			return err
+		}
+	}
+	if m.Scaler != nil && (len(m.Scaler.Mean) != n || len(m.Scaler.Scale) != n) {
+		return errors.New("scaler size does not match features")
+	}
+
+	switch m.Estimator.Type {
+	case TypeLinear:
+		if len(m.Estimator.Coef) != n {
+			return errors.New("coef size does not match features")
+		}
+	case TypeRandomForest, TypeGradientBoosting:
+		if len(m.Estimator.Trees) == 0 {
+			return errors.New("model has no trees")
+		}
+		for i, tree := range m.Estimator.Trees {
+			if err := tree.validate(n); err != nil {
+				return fmt.Errorf("tree %d: %w", i, err)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported model type %q", m.Estimator.Type)
+	}
+
+	return nil
+}
+
+func (t Tree) validate(features int) error {
+	nodes := len(t.ChildrenLeft)
+	if nodes == 0 || len(t.ChildrenRight) != nodes || len(t.Feature) != nodes ||
+		len(t.Threshold) != nodes || len(t.Value) != nodes {
+		return errors.New("inconsistent node arrays")
+	}
+	for i := 0; i < nodes; i++ {
+		if t.ChildrenLeft[i] == -1 {
+			continue
+		}
+		if t.ChildrenLeft[i] <= i || t.ChildrenLeft[i] >= nodes ||
+			t.ChildrenRight[i] <= i || t.ChildrenRight[i] >= nodes {
+			return fmt.Errorf("node %d has invalid children", i)
+		}
+		if t.Feature[i] < 0 || t.Feature[i] >= features {
+			return fmt.Errorf("node %d has invalid feature index", i)
+		}
+	}
+	return nil
+}
+
+// Evaluate возвращает предсказание модели (activity_level) для вектора признаков
+func (m *Model) Evaluate(features model.FeatureVector) (float64, error) {
+	x, err := m.vector(features)
+	if err != nil {
+		return 0, err
+	}
+	return m.Estimator.evaluate(x), nil
+}
+
+// vector собирает признаки в порядке FeatureNames и нормализует их скейлером
+func (m *Model) vector(features model.FeatureVector) ([]float64, error) {
+	x := make([]float64, len(m.FeatureNames))
+	for i, name := range m.FeatureNames {
+		value, err := featureValue(features, name)
+		if err != nil {
+			return nil, err
+		}
+		x[i] = value
+	}
+
+	if m.Scaler != nil {
+		for i := range x {
+			if m.Scaler.Scale[i] != 0 {
+				x[i] = (x[i] - m.Scaler.Mean[i]) / m.Scaler.Scale[i]
+			}
+		}
+	}
+
+	return x, nil
+}
+
+// treeInterval возвращает 95% интервал по предсказаниям отдельных деревьев
+// случайного леса, как activity_interval в predict.py
+func (m *Model) treeInterval(features model.FeatureVector) (*model.Interval, error) {
+	if m.Estimator.Type != TypeRandomForest {
+		return nil, nil
+	}
+
+	x, err := m.vector(features)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]float64, len(m.Estimator.Trees))
+	for i, tree := range m.Estimator.Trees {
+		values[i] = tree.evaluate(x)
+	}
+	sort.Float64s(values)
+
+	return &model.Interval{
+		Lower:  percentile(values, 2.5),
+		Upper:  percentile(values, 97.5),
+		Method: model.IntervalModelTrees,
+	}, nil
+}
+
+// percentile считает перцентиль отсортированных значений с линейной интерполяцией, как numpy.percentile
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower])
+}
+
+func (e Estimator) evaluate(x []float64) float64 {
+	switch e.Type {
+	case TypeLinear:
+		value := e.Intercept
+		for i, c := range e.Coef {
+			value += c * x[i]
+		}
+		return value
+	case TypeRandomForest:
+		var sum float64
+		for _, tree := range e.Trees {
+			sum += tree.evaluate(x)
+		}
+		return sum / float64(len(e.Trees))
+	case TypeGradientBoosting:
+		value := e.InitValue
+		for _, tree := range e.Trees {
+			value += e.LearningRate * tree.evaluate(x)
+		}
+		return value
+	default:
+		return 0
+	}
+}
+
+func (t Tree) evaluate(x []float64) float64 {
+	node := 0
+	for t.ChildrenLeft[node] != -1 {
+		if x[t.Feature[node]] <= t.Threshold[node] {
+			node = t.ChildrenLeft[node]
+		} else {
+			node = t.ChildrenRight[node]
+		}
+	}
+	return t.Value[node]
+}
+
+// Predict строит предсказание так же, как PredictionService.predict в predict.py:
+// activity_level считает модель, остальные поля выводятся из признаков
+func (m *Model) Predict(features model.FeatureVector) (*model.Prediction, error) {
+	activity, err := m.Evaluate(features)
+	if err != nil {
+		return nil, err
+	}
+
+	interval, err := m.treeInterval(features)
+	if err != nil {
+		return nil, err
+	}
+
+	total := features.TotalObjects
+	prediction := &model.Prediction{
+		ActivityLevel:   activity,
+		Trend:           features.NewObjectRate*0.6 - features.ClosureRate*0.4,
+		PredictedNew:    int(float64(total) * features.NewObjectRate),
+		PredictedClosed: int(float64(total) * features.ClosureRate),
+		TotalObjects:    total,
+		ModelUsed:       m.Name,
+	}
+	if interval != nil {
+		prediction.Intervals = &model.PredictionIntervals{Level: 0.95, ActivityLevel: interval}
+	}
+
+	return prediction, nil
+}
+
+// featureValue возвращает признак по имени колонки датасета
+func featureValue(f model.FeatureVector, name string) (float64, error) {
+	switch name {
+	case "avg_area":
+		return f.AvgArea, nil
+	case "avg_dist_to_primary":
+		return f.AvgDistToPrimary, nil
+	case "avg_dist_to_subway":
+		return f.AvgDistToSubway, nil
+	case "closure_rate":
+		return f.ClosureRate, nil
+	case "new_object_rate":
+		return f.NewObjectRate, nil
+	case "object_density":
+		return f.ObjectDensity, nil
+	case "total_objects":
+		return float64(f.TotalObjects), nil
+	case "deseasonalized_trend":
+		return f.DeseasonalizedTrend, nil
+	case "seasonal_amplitude":
+		return f.SeasonalAmplitude, nil
+	default:
+		return 0, fmt.Errorf("unknown feature %q", name)
+	}
+}
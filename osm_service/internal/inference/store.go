@@ -0,0 +1,101 @@
+package inference
+
+import (
+	"fmt"
+	"log"
+	"osm_service/internal/domain/model"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Store хранит экспортированные модели из директории models/
+type Store struct {
+	dir string
+
+	mu     sync.RWMutex
+	models []*Model
+}
+
+// NewStore загружает все модели model_*.json из директории
+func NewStore(dir string) (*Store, error) {
+	s := &Store{dir: dir}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload перечитывает модели с диска. Файлы с ошибками пропускаются с предупреждением.
+func (s *Store) Reload() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "model_*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list models: %w", err)
+	}
+	sort.Strings(paths)
+
+	models := make([]*Model, 0, len(paths))
+	for _, path := range paths {
+		m, err := Load(path)
+		if err != nil {
+			log.Printf("Warning: skipping exported model: %v", err)
+			continue
+		}
+		models = append(models, m)
+	}
+
+	s.mu.Lock()
+	s.models = models
+	s.mu.Unlock()
+
+	log.Printf("Loaded %d exported models from %s", len(models), s.dir)
+	return nil
+}
+
+// Find выбирает модель так же, как find_best_model в predict.py: модель нужного
+// типа объектов, чья область обучения покрывает запрошенную, иначе последнюю из найденных.
+// Если trainPeriod не пустой, рассматриваются только модели этого периода.
+func (s *Store) Find(shopType, trainPeriod string, bounds model.Bounds) (*Model, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var candidates []*Model
+	for _, m := range s.models {
+		if m.ShopType != shopType {
+			continue
+		}
+		if trainPeriod != "" && m.TrainPeriod != trainPeriod {
+			continue
+		}
+		candidates = append(candidates, m)
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w for shop type %s and period %s", ErrModelNotFound, shopType, trainPeriod)
+	}
+
+	for _, m := range candidates {
+		area, err := model.ParseBounds(m.BBox)
+		if err != nil {
+			continue
+		}
+		if area.MinLat <= bounds.MinLat && area.MaxLat >= bounds.MaxLat &&
+			area.MinLon <= bounds.MinLon && area.MaxLon >= bounds.MaxLon {
+			return m, nil
+		}
+	}
+
+	return candidates[len(candidates)-1], nil
+}
+
+// Get возвращает модель по имени
+func (s *Store) Get(name string) (*Model, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, m := range s.models {
+		if m.Name == name {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrModelNotFound, name)
+}
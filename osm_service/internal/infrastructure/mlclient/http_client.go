@@ -2,79 +2,218 @@ package mlclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"osm_service/internal/domain/model"
+	"strings"
+	"time"
 )
 
+// maxErrorBody ограничивает размер тела ошибки, которое попадает в текст ошибки
+const maxErrorBody = 4096
+
+// Config задает таймауты, повторы и параметры circuit breaker для ML клиента
+type Config struct {
+	Timeout          time.Duration // таймаут одного запроса
+	MaxRetries       int           // число повторов после первой попытки
+	RetryBackoff     time.Duration // задержка перед первым повтором, далее удваивается
+	FailureThreshold int           // отказов подряд до размыкания цепи (0 — без breaker)
+	OpenTimeout      time.Duration // время, на которое цепь размыкается
+}
+
+// DefaultConfig возвращает настройки по умолчанию
+func DefaultConfig() Config {
+	return Config{
+		Timeout:          30 * time.Second,
+		MaxRetries:       2,
+		RetryBackoff:     500 * time.Millisecond,
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+	}
+}
+
 type HTTPMLClient struct {
 	baseURL string
 	client  *http.Client
+	config  Config
+	breaker *circuitBreaker
 }
 
-func NewHTTPMLClient(baseURL string) *HTTPMLClient {
+func NewHTTPMLClient(baseURL string, config Config) *HTTPMLClient {
 	return &HTTPMLClient{
-		baseURL: baseURL,
-		client:  &http.Client{},
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client: &http.Client{
+			Timeout: config.Timeout,
+		},
+		config:  config,
+		breaker: newCircuitBreaker(config.FailureThreshold, config.OpenTimeout),
 	}
 }
 
 // GetAvailableModels возвращает список доступных моделей
-func (c *HTTPMLClient) GetAvailableModels() ([]model.ModelInfo, error) {
-	resp, err := c.client.Get(fmt.Sprintf("%s/models", c.baseURL))
+func (c *HTTPMLClient) GetAvailableModels(ctx context.Context) ([]model.ModelInfo, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/models", nil)
 	if err != nil {
-		return nil, fmt.Errorf("error getting models: %v", err)
+		return nil, fmt.Errorf("error getting models: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, readError(resp)
 	}
 
 	var models []model.ModelInfo
 	if err := json.NewDecoder(resp.Body).Decode(&models); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+		return nil, fmt.Errorf("error decoding response: %w", err)
 	}
 
 	return models, nil
 }
 
 // GetPrediction получает предсказание для указанной области
-func (c *HTTPMLClient) GetPrediction(bbox string, modelName string, predictionYear string) (*model.Prediction, error) {
+func (c *HTTPMLClient) GetPrediction(ctx context.Context, query model.PredictionQuery) (*model.Prediction, error) {
 	// Формируем запрос
-	reqBody := map[string]interface{}{
-		"bbox":            bbox,
-		"shop_type":       modelName,
-		"prediction_year": predictionYear,
-		"train_period":    "2010-2024",
+	reqBody := predictRequest{
+		SchemaVersion:  SchemaVersion,
+		BBox:           query.BBox,
+		ShopType:       query.ShopType,
+		PredictionYear: query.PredictionYear,
+		TrainPeriod:    query.TrainPeriod,
+		Features:       query.Features,
+		Model:          query.Model,
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("error marshaling request: %v", err)
+		return nil, fmt.Errorf("error marshaling request: %w", err)
 	}
 
-	// Отправляем запрос
-	resp, err := c.client.Post(
-		fmt.Sprintf("%s/predict", c.baseURL),
-		"application/json",
-		bytes.NewBuffer(jsonBody),
-	)
+	// Отправляем запрос. POST /predict не меняет состояние ML сервиса,
+	// поэтому его можно повторять так же, как GET
+	resp, err := c.do(ctx, http.MethodPost, "/predict", jsonBody)
 	if err != nil {
-		return nil, fmt.Errorf("error sending request: %v", err)
+		return nil, fmt.Errorf("error sending request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, readError(resp)
 	}
 
 	// Декодируем ответ
-	var prediction model.Prediction
-	if err := json.NewDecoder(resp.Body).Decode(&prediction); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+	var result predictResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+	if err := checkSchemaVersion(result.SchemaVersion); err != nil {
+		return nil, err
 	}
 
-	return &prediction, nil
+	prediction := &model.Prediction{
+		ActivityLevel:   result.ActivityLevel,
+		Trend:           result.Trend,
+		PredictedNew:    result.PredictedNew,
+		PredictedClosed: result.PredictedClosed,
+		TotalObjects:    result.TotalObjects,
+		ModelUsed:       result.ModelUsed,
+	}
+	if len(result.ActivityInterval) == 2 {
+		prediction.Intervals = &model.PredictionIntervals{
+			Level: activityIntervalLevel,
+			ActivityLevel: &model.Interval{
+				Lower:  result.ActivityInterval[0],
+				Upper:  result.ActivityInterval[1],
+				Method: model.IntervalModelTrees,
+			},
+		}
+	}
+
+	return prediction, nil
+}
+
+// do отправляет идемпотентный запрос с повторами при сетевых ошибках и ответах
+// шлюза (502/503/504). Ответы 4xx и остальные 5xx возвращаются вызывающему без повторов.
+// Circuit breaker учитывает как отказы только сетевые ошибки, таймауты и ответы шлюза.
+func (c *HTTPMLClient) do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	backoff := c.config.RetryBackoff
+	var lastErr error
+
+	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			log.Printf("Retrying ML service request %s %s (attempt %d): %v", method, path, attempt+1, lastErr)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		if !c.breaker.Allow() {
+			return nil, fmt.Errorf("%w: circuit breaker is open", model.ErrMLServiceUnavailable)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+		if err != nil {
+			c.breaker.Release()
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			// Отмена запроса клиентом не говорит о состоянии ML сервиса
+			if ctx.Err() != nil {
+				c.breaker.Release()
+				return nil, ctx.Err()
+			}
+			c.breaker.Failure()
+			lastErr = fmt.Errorf("%w: %v", model.ErrMLServiceUnavailable, err)
+			continue
+		}
+
+		if resp.StatusCode < http.StatusInternalServerError {
+			c.breaker.Success()
+			return resp, nil
+		}
+
+		lastErr = readError(resp)
+		resp.Body.Close()
+		// Отказом считаются только ответы шлюза: 500 означает, что сервис работает,
+		// но не смог обработать этот запрос, и не должен размыкать цепь для остальных
+		if !errors.Is(lastErr, model.ErrMLServiceUnavailable) {
+			c.breaker.Success()
+			return nil, lastErr
+		}
+		c.breaker.Failure()
+	}
+
+	return nil, lastErr
+}
+
+// readError превращает ответ ML сервиса с ошибкой в model.MLServiceError,
+// сохраняя сообщение из тела ответа
+func readError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	message := strings.TrimSpace(string(body))
+	var parsed errorResponse
+	if err := json.Unmarshal(body, &parsed); err == nil && parsed.Error != "" {
+		message = parsed.Error
+	}
+	if message == "" {
+		message = resp.Status
+	}
+
+	return &model.MLServiceError{
+		StatusCode: resp.StatusCode,
+		Message:    message,
+	}
 }
@@ -0,0 +1,336 @@
+package mlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"osm_service/internal/domain/model"
+)
+
+// testConfig — быстрые повторы и breaker для тестов
+func testConfig() Config {
+	return Config{
+		Timeout:          time.Second,
+		MaxRetries:       2,
+		RetryBackoff:     time.Millisecond,
+		FailureThreshold: 0,
+		OpenTimeout:      50 * time.Millisecond,
+	}
+}
+
+// replay отвечает записанным ответом ML сервиса из testdata
+func replay(t *testing.T, status int, name string) http.HandlerFunc {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read recording: %v", err)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(body)
+	}
+}
+
+func testQuery() model.PredictionQuery {
+	return model.PredictionQuery{
+		BBox:           "55.70,37.50,55.80,37.70",
+		ShopType:       "cafe",
+		PredictionYear: "2025",
+		TrainPeriod:    "2019-2023",
+		Features:       &model.FeatureVector{TotalObjects: 42, NewObjectRate: 0.1, ClosureRate: 0.02},
+		Model:          "model_cafe_2019_2023_55.70_37.50_55.80_37.70",
+	}
+}
+
+func TestGetPredictionContract(t *testing.T) {
+	var request map[string]any
+	recorded := replay(t, http.StatusOK, "predict_ok.json")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/predict" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		recorded(w, r)
+	}))
+	defer server.Close()
+
+	prediction, err := NewHTTPMLClient(server.URL, testConfig()).GetPrediction(context.Background(), testQuery())
+	if err != nil {
+		t.Fatalf("GetPrediction: %v", err)
+	}
+
+	if request["schema_version"] != SchemaVersion {
+		t.Errorf("schema_version = %v, want %s", request["schema_version"], SchemaVersion)
+	}
+	if request["model"] != testQuery().Model {
+		t.Errorf("model = %v, want %s", request["model"], testQuery().Model)
+	}
+	for _, field := range []string{"bbox", "shop_type", "prediction_year", "train_period", "features"} {
+		if _, ok := request[field]; !ok {
+			t.Errorf("request has no %s", field)
+		}
+	}
+
+	if prediction.ActivityLevel != 0.6412 || prediction.TotalObjects != 42 || prediction.PredictedNew != 4 || prediction.PredictedClosed != 1 {
+		t.Errorf("unexpected prediction %+v", prediction)
+	}
+	if prediction.ModelUsed != "model_cafe_2019_2023_55.70_37.50_55.80_37.70" {
+		t.Errorf("ModelUsed = %q", prediction.ModelUsed)
+	}
+	if prediction.Intervals == nil || prediction.Intervals.ActivityLevel == nil {
+		t.Fatalf("activity_interval was not decoded")
+	}
+	interval := prediction.Intervals.ActivityLevel
+	if interval.Lower != 0.5120 || interval.Upper != 0.7731 || interval.Method != model.IntervalModelTrees {
+		t.Errorf("unexpected interval %+v", interval)
+	}
+}
+
+func TestGetAvailableModelsContract(t *testing.T) {
+	server := httptest.NewServer(replay(t, http.StatusOK, "models_ok.json"))
+	defer server.Close()
+
+	models, err := NewHTTPMLClient(server.URL, testConfig()).GetAvailableModels(context.Background())
+	if err != nil {
+		t.Fatalf("GetAvailableModels: %v", err)
+	}
+	if len(models) != 1 {
+		t.Fatalf("got %d models, want 1", len(models))
+	}
+	m := models[0]
+	if m.Name != "model_cafe_2019_2023_55.70_37.50_55.80_37.70" || m.ShopType != "cafe" || m.TrainYear != "2019_2023" {
+		t.Errorf("unexpected model %+v", m)
+	}
+	if m.Metrics.MSE != 0.0121 || m.Metrics.R2 != 0.81 {
+		t.Errorf("unexpected metrics %+v", m.Metrics)
+	}
+}
+
+func TestGetPredictionSchemaMismatch(t *testing.T) {
+	server := httptest.NewServer(replay(t, http.StatusOK, "predict_schema_2.json"))
+	defer server.Close()
+
+	_, err := NewHTTPMLClient(server.URL, testConfig()).GetPrediction(context.Background(), testQuery())
+	if err == nil || !strings.Contains(err.Error(), "incompatible ML service schema version 2.0") {
+		t.Fatalf("err = %v, want schema version mismatch", err)
+	}
+}
+
+func TestCheckSchemaVersion(t *testing.T) {
+	for _, version := range []string{"", "1.0", "1.3", "1.9"} {
+		if err := checkSchemaVersion(version); err != nil {
+			t.Errorf("checkSchemaVersion(%q) = %v, want nil", version, err)
+		}
+	}
+	if err := checkSchemaVersion("2.0"); err == nil {
+		t.Errorf("checkSchemaVersion(2.0) = nil, want error")
+	}
+}
+
+func TestReadError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		message     string
+		unavailable bool
+	}{
+		{"json error", http.StatusNotFound, `{"error": "Dataset for shop type cafe and period 2019-2023 not found"}`, "Dataset for shop type cafe and period 2019-2023 not found", false},
+		{"plain text", http.StatusInternalServerError, "boom\n", "boom", false},
+		{"empty gateway error", http.StatusBadGateway, "", "502 Bad Gateway", true},
+		{"json without error field", http.StatusServiceUnavailable, `{"status": "down"}`, `{"status": "down"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Status:     http.StatusText(tt.status),
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+			if tt.body == "" {
+				resp.Status = "502 Bad Gateway"
+			}
+
+			err := readError(resp)
+			var serviceErr *model.MLServiceError
+			if !errors.As(err, &serviceErr) {
+				t.Fatalf("err = %v, want *model.MLServiceError", err)
+			}
+			if serviceErr.StatusCode != tt.status || serviceErr.Message != tt.message {
+				t.Errorf("got %d %q, want %d %q", serviceErr.StatusCode, serviceErr.Message, tt.status, tt.message)
+			}
+			if errors.Is(err, model.ErrMLServiceUnavailable) != tt.unavailable {
+				t.Errorf("errors.Is(ErrMLServiceUnavailable) = %v, want %v", !tt.unavailable, tt.unavailable)
+			}
+		})
+	}
+}
+
+func TestGetPredictionNotFoundIsNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	recorded := replay(t, http.StatusNotFound, "predict_not_found.json")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		recorded(w, r)
+	}))
+	defer server.Close()
+
+	_, err := NewHTTPMLClient(server.URL, testConfig()).GetPrediction(context.Background(), testQuery())
+	var serviceErr *model.MLServiceError
+	if !errors.As(err, &serviceErr) || serviceErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("err = %v, want ML service 404", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("server called %d times, want 1", calls.Load())
+	}
+}
+
+func TestRetriesGatewayErrors(t *testing.T) {
+	var calls atomic.Int32
+	recorded := replay(t, http.StatusOK, "predict_ok.json")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			http.Error(w, "upstream is restarting", http.StatusServiceUnavailable)
+			return
+		}
+		recorded(w, r)
+	}))
+	defer server.Close()
+
+	prediction, err := NewHTTPMLClient(server.URL, testConfig()).GetPrediction(context.Background(), testQuery())
+	if err != nil {
+		t.Fatalf("GetPrediction: %v", err)
+	}
+	if prediction.ActivityLevel != 0.6412 {
+		t.Errorf("ActivityLevel = %v", prediction.ActivityLevel)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("server called %d times, want 3", calls.Load())
+	}
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := NewHTTPMLClient(server.URL, testConfig()).GetPrediction(context.Background(), testQuery())
+	if !errors.Is(err, model.ErrMLServiceUnavailable) {
+		t.Fatalf("err = %v, want ErrMLServiceUnavailable", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("server called %d times, want 1 attempt and 2 retries", calls.Load())
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	var healthy atomic.Bool
+	recorded := replay(t, http.StatusOK, "predict_ok.json")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		recorded(w, r)
+	}))
+	defer server.Close()
+
+	config := testConfig()
+	config.MaxRetries = 0
+	config.FailureThreshold = 2
+	client := NewHTTPMLClient(server.URL, config)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetPrediction(ctx, testQuery()); !errors.Is(err, model.ErrMLServiceUnavailable) {
+			t.Fatalf("request %d: err = %v, want ErrMLServiceUnavailable", i+1, err)
+		}
+	}
+
+	// Цепь разомкнута: запрос отклоняется без обращения к ML сервису
+	_, err := client.GetPrediction(ctx, testQuery())
+	if !errors.Is(err, model.ErrMLServiceUnavailable) || !strings.Contains(err.Error(), "circuit breaker is open") {
+		t.Fatalf("err = %v, want open circuit breaker", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("server called %d times while the breaker is open, want 2", calls.Load())
+	}
+
+	// После cooldown пробный запрос проходит и замыкает цепь
+	healthy.Store(true)
+	time.Sleep(config.OpenTimeout + 10*time.Millisecond)
+	if _, err := client.GetPrediction(ctx, testQuery()); err != nil {
+		t.Fatalf("probe request: %v", err)
+	}
+	if _, err := client.GetPrediction(ctx, testQuery()); err != nil {
+		t.Fatalf("request after recovery: %v", err)
+	}
+	if calls.Load() != 4 {
+		t.Errorf("server called %d times, want 4", calls.Load())
+	}
+}
+
+func TestCircuitBreakerIgnoresServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	config := testConfig()
+	config.MaxRetries = 0
+	config.FailureThreshold = 2
+	client := NewHTTPMLClient(server.URL, config)
+
+	// Ошибки обработки отдельных запросов не размыкают цепь
+	for i := 0; i < 4; i++ {
+		_, err := client.GetPrediction(context.Background(), testQuery())
+		var serviceErr *model.MLServiceError
+		if !errors.As(err, &serviceErr) || serviceErr.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("request %d: err = %v, want ML service 500", i+1, err)
+		}
+	}
+	if calls.Load() != 4 {
+		t.Errorf("server called %d times, want 4", calls.Load())
+	}
+}
+
+func TestCircuitBreakerReopensAfterFailedProbe(t *testing.T) {
+	breaker := newCircuitBreaker(1, 20*time.Millisecond)
+	breaker.Failure()
+	if breaker.Allow() {
+		t.Fatal("breaker allows requests right after opening")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if !breaker.Allow() {
+		t.Fatal("breaker does not allow a probe after cooldown")
+	}
+	if breaker.Allow() {
+		t.Fatal("breaker allows a second request while probing")
+	}
+	breaker.Failure()
+	if breaker.Allow() {
+		t.Fatal("breaker allows requests after a failed probe")
+	}
+}
@@ -0,0 +1,65 @@
+package mlclient
+
+import (
+	"fmt"
+	"osm_service/internal/domain/model"
+	"strings"
+)
+
+// SchemaVersion — версия контракта между сервисом и ML сервисом (predict.py).
+// Мажорная часть должна совпадать, иначе ответ считается несовместимым.
+const SchemaVersion = "1.3"
+
+// activityIntervalLevel — доверительный уровень activity_interval в ответе ML сервиса
+const activityIntervalLevel = 0.95
+
+// predictRequest — тело запроса POST /predict
+type predictRequest struct {
+	SchemaVersion  string `json:"schema_version"`
+	BBox           string `json:"bbox"`
+	ShopType       string `json:"shop_type"`
+	PredictionYear string `json:"prediction_year"`
+	TrainPeriod    string `json:"train_period"`
+
+	// Features появились в версии 1.1; без них ML сервис ищет признаки в датасете
+	Features *model.FeatureVector `json:"features,omitempty"`
+
+	// Model появилась в версии 1.3: имя модели вместо выбора по bbox
+	Model string `json:"model,omitempty"`
+}
+
+// predictResponse — тело успешного ответа POST /predict
+type predictResponse struct {
+	SchemaVersion   string  `json:"schema_version"`
+	ActivityLevel   float64 `json:"activity_level"`
+	Trend           float64 `json:"trend"`
+	PredictedNew    int     `json:"predicted_new"`
+	PredictedClosed int     `json:"predicted_closed"`
+	TotalObjects    int     `json:"total_objects"`
+	ModelUsed       string  `json:"model_used"`
+
+	// ActivityInterval появился в версии 1.2: [нижняя, верхняя] граница 95% интервала
+	// activity_level по разбросу деревьев; только для случайного леса
+	ActivityInterval []float64 `json:"activity_interval,omitempty"`
+}
+
+// errorResponse — тело ответа ML сервиса с ошибкой
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// checkSchemaVersion проверяет совместимость версии схемы ответа.
+// Ответы без версии принимаются: так отвечают сервисы до введения версий.
+func checkSchemaVersion(version string) error {
+	if version == "" {
+		return nil
+	}
+	if major(version) != major(SchemaVersion) {
+		return fmt.Errorf("incompatible ML service schema version %s, expected %s", version, SchemaVersion)
+	}
+	return nil
+}
+
+func major(version string) string {
+	return strings.SplitN(version, ".", 2)[0]
+}
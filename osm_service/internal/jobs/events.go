@@ -0,0 +1,114 @@
+package jobs
+
+import "time"
+
+// Типы событий задачи
+const (
+	EventState    = "state"    // смена состояния задачи
+	EventCluster  = "cluster"  // кластер обработан
+	EventOverpass = "overpass" // выполнен запрос к Overpass
+	EventWarning  = "warning"  // предупреждение во время выполнения
+	EventDone     = "done"     // задача завершена, в Status итоговое состояние
+)
+
+// eventBuffer — число событий, которые может накопить медленный подписчик.
+// Сверх него события отбрасываются: каждое событие несет текущий прогресс,
+// поэтому пропуск промежуточных событий не искажает картину.
+const eventBuffer = 64
+
+// Event — событие выполнения задачи с текущим прогрессом
+type Event struct {
+	Type            string        `json:"type"`
+	Time            time.Time     `json:"time"`
+	State           string        `json:"state"`
+	ClustersDone    int           `json:"clusters_done"`
+	ClustersTotal   int           `json:"clusters_total"`
+	OverpassQueries int           `json:"overpass_queries"`
+	Cluster         *ClusterEvent `json:"cluster,omitempty"`
+	Message         string        `json:"message,omitempty"`
+	Status          *Status       `json:"status,omitempty"`
+}
+
+// ClusterEvent описывает обработанный кластер
+type ClusterEvent struct {
+	Index int    `json:"index"`
+	BBox  string `json:"bbox"`
+	Error string `json:"error,omitempty"`
+}
+
+// StatusEvent возвращает событие с полным состоянием задачи, например EventDone
+// для завершенной задачи
+func StatusEvent(eventType string, status Status) Event {
+	event := newEvent(eventType, status)
+	event.Status = &status
+	return event
+}
+
+func newEvent(eventType string, status Status) Event {
+	return Event{
+		Type:            eventType,
+		Time:            time.Now(),
+		State:           status.State,
+		ClustersDone:    status.ClustersDone,
+		ClustersTotal:   status.ClustersTotal,
+		OverpassQueries: status.OverpassQueries,
+	}
+}
+
+// Subscribe подписывает на события задачи. Возвращает состояние на момент подписки,
+// канал событий и функцию отписки. Канал закрывается, когда задача завершается;
+// для уже завершенной задачи он возвращается закрытым.
+func (j *Job) Subscribe() (Status, <-chan Event, func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	events := make(chan Event, eventBuffer)
+	if finished(j.status.State) {
+		close(events)
+		return j.snapshot(), events, func() {}
+	}
+
+	if j.subscribers == nil {
+		j.subscribers = make(map[chan Event]struct{})
+	}
+	j.subscribers[events] = struct{}{}
+
+	unsubscribe := func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		if _, ok := j.subscribers[events]; ok {
+			delete(j.subscribers, events)
+			close(events)
+		}
+	}
+	return j.snapshot(), events, unsubscribe
+}
+
+// publish рассылает событие подписчикам; вызывается под j.mu
+func (j *Job) publish(eventType string, fill func(*Event)) {
+	if len(j.subscribers) == 0 {
+		return
+	}
+	event := newEvent(eventType, j.status)
+	if fill != nil {
+		fill(&event)
+	}
+	for events := range j.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// closeSubscribers закрывает каналы подписчиков завершенной задачи; вызывается под j.mu
+func (j *Job) closeSubscribers() {
+	for events := range j.subscribers {
+		close(events)
+	}
+	j.subscribers = nil
+}
+
+func finished(state string) bool {
+	return state == StateSucceeded || state == StateFailed || state == StateCancelled
+}
@@ -0,0 +1,338 @@
+// Package jobs выполняет долгие задачи (построение датасетов) в фоне и хранит их состояние
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Состояния задачи
+const (
+	StateQueued    = "queued"
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+	StateCancelled = "cancelled"
+)
+
+// DefaultTTL — сколько по умолчанию хранится состояние завершенной задачи
+const DefaultTTL = 24 * time.Hour
+
+// ErrJobNotFound возвращается для неизвестного идентификатора задачи
+var ErrJobNotFound = errors.New("job not found")
+
+// ErrJobActive возвращается при повторном запуске задачи, которая еще в очереди или выполняется
+var ErrJobActive = errors.New("job is already queued or running")
+
+// ErrQueueFull возвращается, если очередь задач переполнена
+var ErrQueueFull = errors.New("job queue is full")
+
+// ErrJobFinished возвращается при отмене уже завершенной задачи
+var ErrJobFinished = errors.New("job is already finished")
+
+// Task выполняет работу задачи и возвращает ее результат (путь к датасету).
+// Прогресс сообщается через job.
+type Task func(ctx context.Context, job *Job) (string, error)
+
+// ClusterError — ошибка обработки одного кластера
+type ClusterError struct {
+	Index int    `json:"index"`
+	BBox  string `json:"bbox"`
+	Error string `json:"error"`
+}
+
+// Status — снимок состояния задачи для API
+type Status struct {
+	ID            string         `json:"id"`
+	Kind          string         `json:"kind"`
+	State         string         `json:"state"`
+	ClustersDone  int            `json:"clusters_done"`
+	ClustersTotal int            `json:"clusters_total"`
+	Errors        []ClusterError `json:"errors,omitempty"`
+	Warnings      []string       `json:"warnings,omitempty"`
+
+	OverpassQueries int `json:"overpass_queries"`
+	OverpassErrors  int `json:"overpass_errors"`
+
+	Result     string     `json:"result,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Job — задача в очереди менеджера. Методы безопасны для конкурентного вызова.
+type Job struct {
+	mu          sync.Mutex
+	status      Status
+	task        Task
+	cancel      context.CancelFunc
+	subscribers map[chan Event]struct{}
+}
+
+// ID возвращает идентификатор задачи
+func (j *Job) ID() string {
+	return j.status.ID
+}
+
+// SetTotal задает общее число кластеров
+func (j *Job) SetTotal(clusters int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status.ClustersTotal = clusters
+	j.publish(EventState, nil)
+}
+
+// ClusterDone отмечает кластер обработанным; ошибка кластера сохраняется в задаче
+func (j *Job) ClusterDone(index int, bbox string, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status.ClustersDone++
+	cluster := &ClusterEvent{Index: index, BBox: bbox}
+	if err != nil {
+		j.status.Errors = append(j.status.Errors, ClusterError{Index: index, BBox: bbox, Error: err.Error()})
+		cluster.Error = err.Error()
+	}
+	j.publish(EventCluster, func(event *Event) { event.Cluster = cluster })
+}
+
+// Warning сохраняет предупреждение задачи
+func (j *Job) Warning(message string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status.Warnings = append(j.status.Warnings, message)
+	j.publish(EventWarning, func(event *Event) { event.Message = message })
+}
+
+// OverpassQuery учитывает выполненный задачей запрос к Overpass
+func (j *Job) OverpassQuery(duration time.Duration, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status.OverpassQueries++
+	message := fmt.Sprintf("query took %v", duration.Round(time.Millisecond))
+	if err != nil {
+		j.status.OverpassErrors++
+		message = fmt.Sprintf("query failed after %v: %v", duration.Round(time.Millisecond), err)
+	}
+	j.publish(EventOverpass, func(event *Event) { event.Message = message })
+}
+
+// Status возвращает копию текущего состояния задачи
+func (j *Job) Status() Status {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.snapshot()
+}
+
+// snapshot копирует состояние задачи; вызывается под j.mu
+func (j *Job) snapshot() Status {
+	status := j.status
+	status.Errors = append([]ClusterError(nil), j.status.Errors...)
+	status.Warnings = append([]string(nil), j.status.Warnings...)
+	return status
+}
+
+// Manager выполняет задачи пулом воркеров фиксированного размера.
+// Состояние хранится в памяти и теряется при перезапуске. Завершенные задачи
+// хранятся ttl и удаляются при постановке новых задач.
+type Manager struct {
+	mu    sync.Mutex
+	jobs  map[string]*Job
+	queue chan *Job
+	ttl   time.Duration
+}
+
+// NewManager запускает workers воркеров; задачи сверх них ждут в очереди
+func NewManager(workers int) *Manager {
+	if workers < 1 {
+		workers = 1
+	}
+	m := &Manager{
+		jobs:  make(map[string]*Job),
+		queue: make(chan *Job, 1024),
+		ttl:   DefaultTTL,
+	}
+	for i := 0; i < workers; i++ {
+		go m.worker()
+	}
+	return m
+}
+
+// SetTTL задает, сколько хранится состояние завершенной задачи; 0 — до перезапуска
+func (m *Manager) SetTTL(ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ttl = ttl
+}
+
+// Submit ставит задачу в очередь и возвращает ее состояние
+func (m *Manager) Submit(kind string, task Task) (Status, error) {
+	id, err := newJobID()
+	if err != nil {
+		return Status{}, err
+	}
+	return m.enqueue(id, kind, task)
+}
+
+// Resume снова ставит в очередь задачу с известным идентификатором, например
+// прерванную перезапуском сервиса. Завершенная задача с этим ID заменяется новой.
+func (m *Manager) Resume(id string, kind string, task Task) (Status, error) {
+	return m.enqueue(id, kind, task)
+}
+
+func (m *Manager) enqueue(id string, kind string, task Task) (Status, error) {
+	job := &Job{
+		status: Status{ID: id, Kind: kind, State: StateQueued, CreatedAt: time.Now()},
+		task:   task,
+	}
+
+	m.mu.Lock()
+	m.evictExpired(time.Now())
+	previous, ok := m.jobs[id]
+	if ok {
+		state := previous.Status().State
+		if state == StateQueued || state == StateRunning {
+			m.mu.Unlock()
+			return Status{}, ErrJobActive
+		}
+	}
+	m.jobs[id] = job
+	m.mu.Unlock()
+
+	select {
+	case m.queue <- job:
+	default:
+		m.mu.Lock()
+		if previous != nil {
+			m.jobs[id] = previous
+		} else {
+			delete(m.jobs, id)
+		}
+		m.mu.Unlock()
+		return Status{}, ErrQueueFull
+	}
+
+	return job.Status(), nil
+}
+
+// evictExpired удаляет задачи, завершенные раньше чем ttl назад; вызывается под m.mu
+func (m *Manager) evictExpired(now time.Time) {
+	if m.ttl <= 0 {
+		return
+	}
+	for id, job := range m.jobs {
+		if finished := job.Status().FinishedAt; finished != nil && now.Sub(*finished) > m.ttl {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// Get возвращает состояние задачи
+func (m *Manager) Get(id string) (Status, error) {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	m.mu.Unlock()
+	if !ok {
+		return Status{}, ErrJobNotFound
+	}
+	return job.Status(), nil
+}
+
+// Subscribe подписывает на события задачи, см. Job.Subscribe
+func (m *Manager) Subscribe(id string) (Status, <-chan Event, func(), error) {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	m.mu.Unlock()
+	if !ok {
+		return Status{}, nil, nil, ErrJobNotFound
+	}
+	status, events, unsubscribe := job.Subscribe()
+	return status, events, unsubscribe, nil
+}
+
+// Cancel отменяет задачу: задача в очереди не будет запущена, у выполняющейся
+// отменяется контекст, и она завершается в состоянии cancelled
+func (m *Manager) Cancel(id string) (Status, error) {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	m.mu.Unlock()
+	if !ok {
+		return Status{}, ErrJobNotFound
+	}
+
+	job.mu.Lock()
+	switch job.status.State {
+	case StateQueued:
+		now := time.Now()
+		job.status.State = StateCancelled
+		job.status.FinishedAt = &now
+		job.closeSubscribers()
+	case StateRunning:
+		job.cancel()
+	default:
+		job.mu.Unlock()
+		return job.Status(), ErrJobFinished
+	}
+	job.mu.Unlock()
+
+	return job.Status(), nil
+}
+
+func (m *Manager) worker() {
+	for job := range m.queue {
+		m.run(job)
+	}
+}
+
+func (m *Manager) run(job *Job) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	job.mu.Lock()
+	if job.status.State != StateQueued {
+		// Задача отменена, пока ждала в очереди
+		job.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	job.status.State = StateRunning
+	job.status.StartedAt = &now
+	job.cancel = cancel
+	job.publish(EventState, nil)
+	job.mu.Unlock()
+
+	log.Printf("Job %s (%s) started", job.status.ID, job.status.Kind)
+	result, err := job.task(ctx, job)
+
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	finished := time.Now()
+	job.status.FinishedAt = &finished
+	switch {
+	case ctx.Err() != nil:
+		job.status.State = StateCancelled
+	case err != nil:
+		job.status.State = StateFailed
+		job.status.Error = err.Error()
+	default:
+		job.status.State = StateSucceeded
+		job.status.Result = result
+	}
+	job.closeSubscribers()
+	log.Printf("Job %s finished: %s", job.status.ID, job.status.State)
+}
+
+// newJobID возвращает случайный идентификатор задачи
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func waitFinished(t *testing.T, m *Manager, id string) Status {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		status, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%s): %v", id, err)
+		}
+		if status.FinishedAt != nil {
+			return status
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return Status{}
+}
+
+func TestManagerEvictsFinishedJobs(t *testing.T) {
+	m := NewManager(1)
+	m.SetTTL(20 * time.Millisecond)
+
+	done := func(ctx context.Context, job *Job) (string, error) { return "ok", nil }
+	first, err := m.Submit("test", done)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFinished(t, m, first.ID)
+
+	time.Sleep(30 * time.Millisecond)
+	second, err := m.Submit("test", done)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Get(first.ID); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("expired job: err = %v, want ErrJobNotFound", err)
+	}
+	if _, err := m.Get(second.ID); err != nil {
+		t.Errorf("new job was evicted: %v", err)
+	}
+}
+
+func TestManagerKeepsActiveJobs(t *testing.T) {
+	m := NewManager(1)
+	m.SetTTL(time.Nanosecond)
+
+	release := make(chan struct{})
+	running, err := m.Submit("test", func(ctx context.Context, job *Job) (string, error) {
+		<-release
+		return "ok", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer close(release)
+
+	time.Sleep(5 * time.Millisecond)
+	if _, err := m.Submit("test", func(ctx context.Context, job *Job) (string, error) { return "", nil }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Get(running.ID); err != nil {
+		t.Errorf("running job was evicted: %v", err)
+	}
+}
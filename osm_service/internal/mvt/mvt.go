@@ -0,0 +1,255 @@
+// Package mvt кодирует векторные тайлы Mapbox Vector Tile 2.1 для веб-карт
+// (https://github.com/mapbox/vector-tile-spec). Поддерживаются точки и
+// прямоугольники — ячейки кластеров, этого достаточно для слоев сервиса.
+package mvt
+
+import (
+	"fmt"
+	"math"
+	"osm_service/internal/domain/model"
+)
+
+// MediaType — тип содержимого векторного тайла
+const MediaType = "application/vnd.mapbox-vector-tile"
+
+// Extent — размер тайла во внутренних координатах
+const Extent = 4096
+
+// buffer — запас за краем тайла, чтобы обводка полигонов на стыке тайлов не обрывалась
+const buffer = 64
+
+// MaxZoom ограничивает масштаб тайлов
+const MaxZoom = 22
+
+// Типы геометрии и команды геометрии по спецификации
+const (
+	geomPoint   = 1
+	geomPolygon = 3
+
+	cmdMoveTo    = 1
+	cmdLineTo    = 2
+	cmdClosePath = 7
+)
+
+// TileID — адрес тайла в схеме XYZ (Web Mercator)
+type TileID struct {
+	Z, X, Y int
+}
+
+// Validate проверяет, что тайл существует на масштабе Z
+func (t TileID) Validate() error {
+	if t.Z < 0 || t.Z > MaxZoom {
+		return fmt.Errorf("zoom must be in [0, %d], got %d", MaxZoom, t.Z)
+	}
+	n := 1 << t.Z
+	if t.X < 0 || t.X >= n || t.Y < 0 || t.Y >= n {
+		return fmt.Errorf("tile %d/%d/%d is outside of zoom %d", t.Z, t.X, t.Y, t.Z)
+	}
+	return nil
+}
+
+// Bounds возвращает область тайла в градусах
+func (t TileID) Bounds() model.Bounds {
+	n := float64(int(1) << t.Z)
+	return model.Bounds{
+		MinLat: tileLat(float64(t.Y+1), n),
+		MinLon: float64(t.X)/n*360 - 180,
+		MaxLat: tileLat(float64(t.Y), n),
+		MaxLon: float64(t.X+1)/n*360 - 180,
+	}
+}
+
+func tileLat(y, n float64) float64 {
+	return math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+}
+
+// project переводит координаты в систему тайла: начало в северо-западном углу, ось y вниз
+func (t TileID) project(lat, lon float64) (float64, float64) {
+	n := float64(int(1) << t.Z)
+	lat = math.Max(math.Min(lat, 85.0511), -85.0511)
+	rad := lat * math.Pi / 180
+	x := (lon + 180) / 360 * n
+	y := (1 - math.Log(math.Tan(rad)+1/math.Cos(rad))/math.Pi) / 2 * n
+	return (x - float64(t.X)) * Extent, (y - float64(t.Y)) * Extent
+}
+
+// Feature — объект слоя. Значения атрибутов: string, bool, int, int64, float64.
+type Feature struct {
+	ID         uint64
+	Properties map[string]any
+
+	geomType uint32
+	geometry []uint32
+}
+
+// Layer — слой тайла
+type Layer struct {
+	Name     string
+	Features []Feature
+}
+
+// NewLayer возвращает пустой слой name
+func NewLayer(name string) *Layer {
+	return &Layer{Name: name}
+}
+
+// AddPoint добавляет точку; точки вне тайла пропускаются
+func (l *Layer) AddPoint(tile TileID, lat, lon float64, id uint64, properties map[string]any) {
+	x, y := tile.project(lat, lon)
+	if x < 0 || x >= Extent || y < 0 || y >= Extent {
+		return
+	}
+	l.Features = append(l.Features, Feature{
+		ID:         id,
+		Properties: properties,
+		geomType:   geomPoint,
+		geometry:   []uint32{command(cmdMoveTo, 1), zigzag(int(x)), zigzag(int(y))},
+	})
+}
+
+// AddBounds добавляет прямоугольник bounds, обрезанный по краю тайла с запасом.
+// Прямоугольники, не пересекающие тайл, пропускаются.
+func (l *Layer) AddBounds(tile TileID, bounds model.Bounds, id uint64, properties map[string]any) {
+	minX, minY := tile.project(bounds.MaxLat, bounds.MinLon)
+	maxX, maxY := tile.project(bounds.MinLat, bounds.MaxLon)
+
+	x0, y0 := clip(minX), clip(minY)
+	x1, y1 := clip(maxX), clip(maxY)
+	if x0 >= x1 || y0 >= y1 {
+		return
+	}
+
+	// Внешнее кольцо в координатах тайла обходится по часовой стрелке:
+	// северо-запад, северо-восток, юго-восток, юго-запад
+	l.Features = append(l.Features, Feature{
+		ID:         id,
+		Properties: properties,
+		geomType:   geomPolygon,
+		geometry: []uint32{
+			command(cmdMoveTo, 1), zigzag(x0), zigzag(y0),
+			command(cmdLineTo, 3),
+			zigzag(x1 - x0), zigzag(0),
+			zigzag(0), zigzag(y1 - y0),
+			zigzag(x0 - x1), zigzag(0),
+			command(cmdClosePath, 1),
+		},
+	})
+}
+
+func clip(v float64) int {
+	return int(math.Round(math.Max(math.Min(v, Extent+buffer), -buffer)))
+}
+
+func command(id, count uint32) uint32 {
+	return id&0x7 | count<<3
+}
+
+func zigzag(v int) uint32 {
+	return uint32(int32(v)<<1 ^ int32(v)>>31)
+}
+
+// Encode кодирует слои в тайл. Пустые слои пропускаются.
+func Encode(layers ...*Layer) ([]byte, error) {
+	var tile []byte
+	for _, layer := range layers {
+		if layer == nil || len(layer.Features) == 0 {
+			continue
+		}
+		data, err := layer.encode()
+		if err != nil {
+			return nil, fmt.Errorf("layer %s: %w", layer.Name, err)
+		}
+		tile = appendBytes(tile, 3, data)
+	}
+	return tile, nil
+}
+
+// encode кодирует слой; ключи и значения атрибутов хранятся в слое один раз
+func (l *Layer) encode() ([]byte, error) {
+	var keys []string
+	keyIndex := make(map[string]uint32)
+	var values [][]byte
+	valueIndex := make(map[string]uint32)
+
+	var data []byte
+	data = appendVarintField(data, 15, 2)
+	data = appendBytes(data, 1, []byte(l.Name))
+
+	for _, feature := range l.Features {
+		var tags []uint32
+		for _, key := range sortedKeys(feature.Properties) {
+			value, err := encodeValue(feature.Properties[key])
+			if err != nil {
+				return nil, fmt.Errorf("property %s: %w", key, err)
+			}
+			if value == nil {
+				continue
+			}
+
+			k, ok := keyIndex[key]
+			if !ok {
+				k = uint32(len(keys))
+				keyIndex[key] = k
+				keys = append(keys, key)
+			}
+			v, ok := valueIndex[string(value)]
+			if !ok {
+				v = uint32(len(values))
+				valueIndex[string(value)] = v
+				values = append(values, value)
+			}
+			tags = append(tags, k, v)
+		}
+
+		var f []byte
+		if feature.ID != 0 {
+			f = appendVarintField(f, 1, feature.ID)
+		}
+		if len(tags) > 0 {
+			f = appendPacked(f, 2, tags)
+		}
+		f = appendVarintField(f, 3, uint64(feature.geomType))
+		f = appendPacked(f, 4, feature.geometry)
+		data = appendBytes(data, 2, f)
+	}
+
+	for _, key := range keys {
+		data = appendBytes(data, 3, []byte(key))
+	}
+	for _, value := range values {
+		data = appendBytes(data, 4, value)
+	}
+	data = appendVarintField(data, 5, Extent)
+	return data, nil
+}
+
+// encodeValue кодирует сообщение Value; nil пропускается
+func encodeValue(value any) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return appendBytes(nil, 1, []byte(v)), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, nil
+		}
+		return appendFixed64(appendKey(nil, 3, wireFixed64), math.Float64bits(v)), nil
+	case int:
+		return appendVarintField(nil, 6, zigzag64(int64(v))), nil
+	case int64:
+		return appendVarintField(nil, 6, zigzag64(v)), nil
+	case bool:
+		b := uint64(0)
+		if v {
+			b = 1
+		}
+		return appendVarintField(nil, 7, b), nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+func zigzag64(v int64) uint64 {
+	return uint64(v<<1 ^ v>>63)
+}
@@ -0,0 +1,394 @@
+package mvt
+
+import (
+	"encoding/binary"
+	"math"
+	"osm_service/internal/domain/model"
+	"testing"
+)
+
+// Разбор тайла для проверок: только поля vector_tile.proto, которые пишет Encode
+
+type decodedLayer struct {
+	version  uint64
+	name     string
+	extent   uint64
+	keys     []string
+	values   []any
+	features []decodedFeature
+}
+
+type decodedFeature struct {
+	id       uint64
+	tags     []uint32
+	geomType uint64
+	geometry []uint32
+}
+
+// properties возвращает атрибуты объекта по таблицам ключей и значений слоя
+func (l decodedLayer) properties(t *testing.T, f decodedFeature) map[string]any {
+	t.Helper()
+	if len(f.tags)%2 != 0 {
+		t.Fatalf("odd number of tags: %v", f.tags)
+	}
+	properties := make(map[string]any)
+	for i := 0; i < len(f.tags); i += 2 {
+		if int(f.tags[i]) >= len(l.keys) || int(f.tags[i+1]) >= len(l.values) {
+			t.Fatalf("tag %d=%d is outside of keys %v and values %v", f.tags[i], f.tags[i+1], l.keys, l.values)
+		}
+		properties[l.keys[f.tags[i]]] = l.values[f.tags[i+1]]
+	}
+	return properties
+}
+
+type protoField struct {
+	num    uint64
+	varint uint64
+	bytes  []byte
+}
+
+func readFields(t *testing.T, data []byte) []protoField {
+	t.Helper()
+	var fields []protoField
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			t.Fatalf("invalid field key")
+		}
+		data = data[n:]
+		field := protoField{num: key >> 3}
+		switch key & 0x7 {
+		case wireVarint:
+			field.varint, n = binary.Uvarint(data)
+			if n <= 0 {
+				t.Fatalf("invalid varint of field %d", field.num)
+			}
+			data = data[n:]
+		case wireFixed64:
+			field.varint = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || int(length) > len(data[n:]) {
+				t.Fatalf("invalid length of field %d", field.num)
+			}
+			field.bytes = data[n : n+int(length)]
+			data = data[n+int(length):]
+		default:
+			t.Fatalf("unexpected wire type %d of field %d", key&0x7, field.num)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func readPacked(t *testing.T, data []byte) []uint32 {
+	t.Helper()
+	var values []uint32
+	for len(data) > 0 {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			t.Fatalf("invalid packed varint")
+		}
+		values = append(values, uint32(v))
+		data = data[n:]
+	}
+	return values
+}
+
+func decodeTile(t *testing.T, data []byte) []decodedLayer {
+	t.Helper()
+	var layers []decodedLayer
+	for _, field := range readFields(t, data) {
+		if field.num != 3 {
+			t.Fatalf("unexpected tile field %d", field.num)
+		}
+		layers = append(layers, decodeLayer(t, field.bytes))
+	}
+	return layers
+}
+
+func decodeLayer(t *testing.T, data []byte) decodedLayer {
+	t.Helper()
+	var layer decodedLayer
+	for _, field := range readFields(t, data) {
+		switch field.num {
+		case 15:
+			layer.version = field.varint
+		case 1:
+			layer.name = string(field.bytes)
+		case 2:
+			var feature decodedFeature
+			for _, f := range readFields(t, field.bytes) {
+				switch f.num {
+				case 1:
+					feature.id = f.varint
+				case 2:
+					feature.tags = readPacked(t, f.bytes)
+				case 3:
+					feature.geomType = f.varint
+				case 4:
+					feature.geometry = readPacked(t, f.bytes)
+				}
+			}
+			layer.features = append(layer.features, feature)
+		case 3:
+			layer.keys = append(layer.keys, string(field.bytes))
+		case 4:
+			value := readFields(t, field.bytes)
+			if len(value) != 1 {
+				t.Fatalf("value must have one field, got %d", len(value))
+			}
+			switch value[0].num {
+			case 1:
+				layer.values = append(layer.values, string(value[0].bytes))
+			case 3:
+				layer.values = append(layer.values, math.Float64frombits(value[0].varint))
+			case 6:
+				v := value[0].varint
+				layer.values = append(layer.values, int64(v>>1)^-int64(v&1))
+			case 7:
+				layer.values = append(layer.values, value[0].varint == 1)
+			default:
+				t.Fatalf("unexpected value field %d", value[0].num)
+			}
+		case 5:
+			layer.extent = field.varint
+		}
+	}
+	return layer
+}
+
+func unzigzag(v uint32) int {
+	return int(int32(v>>1) ^ -int32(v&1))
+}
+
+// ring переводит команды геометрии полигона в абсолютные координаты вершин кольца
+func ring(t *testing.T, geometry []uint32) [][2]int {
+	t.Helper()
+	var points [][2]int
+	x, y := 0, 0
+	for i := 0; i < len(geometry); {
+		id, count := geometry[i]&0x7, int(geometry[i]>>3)
+		i++
+		switch id {
+		case cmdMoveTo, cmdLineTo:
+			for j := 0; j < count; j++ {
+				x += unzigzag(geometry[i])
+				y += unzigzag(geometry[i+1])
+				i += 2
+				points = append(points, [2]int{x, y})
+			}
+		case cmdClosePath:
+			if count != 1 {
+				t.Fatalf("ClosePath count must be 1, got %d", count)
+			}
+		default:
+			t.Fatalf("unexpected command %d", id)
+		}
+	}
+	return points
+}
+
+func TestZigzag(t *testing.T) {
+	tests := []struct {
+		value int
+		want  uint32
+	}{
+		{0, 0},
+		{-1, 1},
+		{1, 2},
+		{-2, 3},
+		{2048, 4096},
+		{-4160, 8319},
+	}
+	for _, tt := range tests {
+		if got := zigzag(tt.value); got != tt.want {
+			t.Errorf("zigzag(%d) = %d, want %d", tt.value, got, tt.want)
+		}
+		if got := unzigzag(tt.want); got != tt.value {
+			t.Errorf("unzigzag(%d) = %d, want %d", tt.want, got, tt.value)
+		}
+	}
+}
+
+func TestEncodeLayer(t *testing.T) {
+	tile := TileID{Z: 14, X: 9903, Y: 5121}
+	bounds := tile.Bounds()
+	center := model.Bounds{
+		MinLat: bounds.MinLat + (bounds.MaxLat-bounds.MinLat)/4,
+		MinLon: bounds.MinLon + (bounds.MaxLon-bounds.MinLon)/4,
+		MaxLat: bounds.MaxLat - (bounds.MaxLat-bounds.MinLat)/4,
+		MaxLon: bounds.MaxLon - (bounds.MaxLon-bounds.MinLon)/4,
+	}
+
+	layer := NewLayer("clusters")
+	layer.AddBounds(tile, center, 1, map[string]any{"density": 12.5, "index": 1, "forecast": true})
+	layer.AddBounds(tile, center, 2, map[string]any{"density": 12.5, "index": 2, "name": "центр"})
+
+	data, err := Encode(layer, NewLayer("empty"))
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	layers := decodeTile(t, data)
+	if len(layers) != 1 {
+		t.Fatalf("expected empty layer to be skipped, got %d layers", len(layers))
+	}
+
+	decoded := layers[0]
+	if decoded.version != 2 {
+		t.Errorf("expected version 2, got %d", decoded.version)
+	}
+	if decoded.extent != Extent {
+		t.Errorf("expected extent %d, got %d", Extent, decoded.extent)
+	}
+	if decoded.name != "clusters" {
+		t.Errorf("expected layer name clusters, got %q", decoded.name)
+	}
+	// Общие ключи и значения хранятся в слое один раз
+	if len(decoded.keys) != 4 {
+		t.Errorf("expected 4 distinct keys, got %v", decoded.keys)
+	}
+	if len(decoded.values) != 5 {
+		t.Errorf("expected 5 distinct values, got %v", decoded.values)
+	}
+	if len(decoded.features) != 2 {
+		t.Fatalf("expected 2 features, got %d", len(decoded.features))
+	}
+
+	want := []map[string]any{
+		{"density": 12.5, "index": int64(1), "forecast": true},
+		{"density": 12.5, "index": int64(2), "name": "центр"},
+	}
+	for i, feature := range decoded.features {
+		if feature.id != uint64(i+1) {
+			t.Errorf("feature %d: expected id %d, got %d", i, i+1, feature.id)
+		}
+		if feature.geomType != geomPolygon {
+			t.Errorf("feature %d: expected polygon, got type %d", i, feature.geomType)
+		}
+		properties := decoded.properties(t, feature)
+		if len(properties) != len(want[i]) {
+			t.Errorf("feature %d: expected properties %v, got %v", i, want[i], properties)
+		}
+		for key, value := range want[i] {
+			if properties[key] != value {
+				t.Errorf("feature %d: expected %s=%v, got %v", i, key, value, properties[key])
+			}
+		}
+	}
+}
+
+func TestAddPoint(t *testing.T) {
+	tile := TileID{Z: 14, X: 9903, Y: 5121}
+	bounds := tile.Bounds()
+
+	layer := NewLayer("pois")
+	// Центр тайла по долготе; широта выбрана в проекции, чтобы попасть в середину по y
+	centerLat := tileLat(float64(tile.Y)+0.5, float64(int(1)<<tile.Z))
+	layer.AddPoint(tile, centerLat, (bounds.MinLon+bounds.MaxLon)/2, 7, nil)
+	layer.AddPoint(tile, bounds.MaxLat+0.01, bounds.MinLon, 8, nil)
+	layer.AddPoint(tile, bounds.MinLat, bounds.MaxLon+0.01, 9, nil)
+
+	if len(layer.Features) != 1 {
+		t.Fatalf("expected points outside the tile to be dropped, got %d features", len(layer.Features))
+	}
+	geometry := layer.Features[0].geometry
+	if len(geometry) != 3 || geometry[0] != command(cmdMoveTo, 1) {
+		t.Fatalf("expected MoveTo(1) with one point, got %v", geometry)
+	}
+	x, y := unzigzag(geometry[1]), unzigzag(geometry[2])
+	if math.Abs(float64(x-Extent/2)) > 1 || math.Abs(float64(y-Extent/2)) > 1 {
+		t.Errorf("expected point near (%d, %d), got (%d, %d)", Extent/2, Extent/2, x, y)
+	}
+}
+
+func TestAddBounds(t *testing.T) {
+	tile := TileID{Z: 14, X: 9903, Y: 5121}
+	bounds := tile.Bounds()
+	width := bounds.MaxLon - bounds.MinLon
+	height := bounds.MaxLat - bounds.MinLat
+
+	t.Run("clockwise ring", func(t *testing.T) {
+		layer := NewLayer("clusters")
+		layer.AddBounds(tile, model.Bounds{
+			MinLat: bounds.MinLat + height/4,
+			MinLon: bounds.MinLon + width/4,
+			MaxLat: bounds.MaxLat - height/4,
+			MaxLon: bounds.MaxLon - width/4,
+		}, 1, nil)
+		if len(layer.Features) != 1 {
+			t.Fatalf("expected 1 feature, got %d", len(layer.Features))
+		}
+
+		points := ring(t, layer.Features[0].geometry)
+		if len(points) != 4 {
+			t.Fatalf("expected 4 ring vertices, got %v", points)
+		}
+		// По спецификации внешнее кольцо в координатах тайла (ось y вниз) обходится
+		// по часовой стрелке, то есть площадь по формуле Гаусса положительна
+		var area int
+		for i := range points {
+			next := points[(i+1)%len(points)]
+			area += points[i][0]*next[1] - next[0]*points[i][1]
+		}
+		if area <= 0 {
+			t.Errorf("expected clockwise exterior ring with positive area, got %d for %v", area, points)
+		}
+		nw := points[0]
+		if math.Abs(float64(nw[0]-Extent/4)) > 2 || nw[1] <= 0 || nw[1] >= Extent/2 {
+			t.Errorf("expected ring to start at the north-west corner, got %v", nw)
+		}
+	})
+
+	t.Run("clipped to buffer", func(t *testing.T) {
+		layer := NewLayer("clusters")
+		layer.AddBounds(tile, model.Bounds{
+			MinLat: bounds.MinLat - height,
+			MinLon: bounds.MinLon - width,
+			MaxLat: bounds.MaxLat + height,
+			MaxLon: bounds.MaxLon + width,
+		}, 1, nil)
+		if len(layer.Features) != 1 {
+			t.Fatalf("expected 1 feature, got %d", len(layer.Features))
+		}
+		for _, point := range ring(t, layer.Features[0].geometry) {
+			for _, v := range point {
+				if v != -buffer && v != Extent+buffer {
+					t.Errorf("expected vertex on the buffer edge, got %v", point)
+				}
+			}
+		}
+	})
+
+	t.Run("outside dropped", func(t *testing.T) {
+		layer := NewLayer("clusters")
+		layer.AddBounds(tile, model.Bounds{
+			MinLat: bounds.MinLat,
+			MinLon: bounds.MaxLon + width,
+			MaxLat: bounds.MaxLat,
+			MaxLon: bounds.MaxLon + 2*width,
+		}, 1, nil)
+		if len(layer.Features) != 0 {
+			t.Errorf("expected bounds outside the tile to be dropped, got %d features", len(layer.Features))
+		}
+	})
+}
+
+func TestTileIDValidate(t *testing.T) {
+	tests := []struct {
+		tile  TileID
+		valid bool
+	}{
+		{TileID{Z: 0, X: 0, Y: 0}, true},
+		{TileID{Z: 14, X: 9903, Y: 5121}, true},
+		{TileID{Z: 1, X: 2, Y: 0}, false},
+		{TileID{Z: -1}, false},
+		{TileID{Z: MaxZoom + 1}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.tile.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%+v: expected valid=%v, got %v", tt.tile, tt.valid, err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package mvt
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// Минимальная запись protobuf: только типы полей, которые используются в vector_tile.proto
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+func appendVarint(b []byte, v uint64) []byte {
+	return binary.AppendUvarint(b, v)
+}
+
+func appendKey(b []byte, field, wire uint64) []byte {
+	return appendVarint(b, field<<3|wire)
+}
+
+func appendVarintField(b []byte, field, v uint64) []byte {
+	return appendVarint(appendKey(b, field, wireVarint), v)
+}
+
+func appendFixed64(b []byte, v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(b, v)
+}
+
+func appendBytes(b []byte, field uint64, data []byte) []byte {
+	b = appendKey(b, field, wireBytes)
+	b = appendVarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendPacked(b []byte, field uint64, values []uint32) []byte {
+	var data []byte
+	for _, v := range values {
+		data = appendVarint(data, uint64(v))
+	}
+	return appendBytes(b, field, data)
+}
+
+// sortedKeys возвращает ключи атрибутов по порядку, чтобы тайл не зависел от обхода map
+func sortedKeys(properties map[string]any) []string {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}